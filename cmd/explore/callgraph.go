@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/value"
+)
+
+// callGraph is the call graph of an LLVM IR module, computed from the call
+// instructions and invoke terminators of its function definitions.
+type callGraph struct {
+	// Functions of the module, in module order.
+	funcs []*ir.Func
+	// Callees of each function, in order of first call; indexed by caller.
+	callees map[*ir.Func][]*ir.Func
+}
+
+// newCallGraph returns the call graph of the given module. Indirect calls are
+// not included, as their callees are not known statically.
+func newCallGraph(m *ir.Module) *callGraph {
+	cg := &callGraph{
+		funcs:   m.Funcs,
+		callees: make(map[*ir.Func][]*ir.Func),
+	}
+	for _, f := range m.Funcs {
+		seen := make(map[*ir.Func]bool)
+		addCallee := func(v value.Value) {
+			callee, ok := calleeFunc(v)
+			if !ok || seen[callee] {
+				return
+			}
+			seen[callee] = true
+			cg.callees[f] = append(cg.callees[f], callee)
+		}
+		for _, block := range f.Blocks {
+			for _, inst := range block.Insts {
+				if inst, ok := inst.(*ir.InstCall); ok {
+					addCallee(inst.Callee)
+				}
+			}
+			if term, ok := block.Term.(*ir.TermInvoke); ok {
+				addCallee(term.Invokee)
+			}
+		}
+	}
+	return cg
+}
+
+// calleeFunc returns the function called through the given callee value,
+// looking through bitcast constant expressions. The boolean return value
+// indicates success.
+func calleeFunc(v value.Value) (*ir.Func, bool) {
+	switch v := v.(type) {
+	case *ir.Func:
+		return v, true
+	case *constant.ExprBitCast:
+		return calleeFunc(v.From)
+	}
+	return nil, false
+}
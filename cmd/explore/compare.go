@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/mewmew/lnp/pkg/cfa/primitive"
+	"github.com/pkg/errors"
+)
+
+func compareUsage() {
+	const use = `
+Compare two explorations of the same LLVM IR module.
+
+Usage:
+
+	explore diff [OPTION]... OLD_DIR NEW_DIR
+
+Flags:
+`
+	fmt.Fprintln(os.Stderr, use[1:])
+}
+
+// compareMain compares two explorations of the same LLVM IR module, as
+// specified by the command line arguments of the `explore diff` command.
+func compareMain(args []string) {
+	// Parse command line arguments.
+	var (
+		// force specifies whether to force overwrite existing output directory.
+		force bool
+		// output specifies the output directory.
+		output string
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+		// style specifies the style used for syntax highlighting.
+		style string
+	)
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.BoolVar(&force, "f", false, "force overwrite existing output directory")
+	fs.StringVar(&output, "o", "diff_explore", "output directory")
+	fs.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	fs.StringVar(&style, "style", "vs", "style used for syntax highlighting (borland, monokai, vs, ...)")
+	fs.Usage = func() {
+		compareUsage()
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	oldDir, newDir := fs.Arg(0), fs.Arg(1)
+	if quiet {
+		// Mute debug messages if `-q` is set.
+		dbg.SetOutput(ioutil.Discard)
+	}
+	e := &explorer{
+		outputDir: output,
+		style:     style,
+	}
+	if err := e.compare(oldDir, newDir, force); err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
+
+// funcComparison is the comparison of two explorations of a function.
+type funcComparison struct {
+	// Function name.
+	Name string
+	// Link to the comparison page of the function; or empty if the function is
+	// only present in one of the explorations.
+	Link string
+	// Comparison status; e.g. "identical" or "diverges at step 3".
+	Status string
+	// Step at which the recovered control flow primitives first diverge; or 0
+	// if identical.
+	Divergence int
+	// Comparison of each step.
+	Steps []stepComparison
+	// Side-by-side diff of the final reconstructed Go source code.
+	GoRows []diffRow
+}
+
+// stepComparison is the comparison of a step of two explorations of a
+// function.
+type stepComparison struct {
+	// Step number (1-based).
+	Step int
+	// Description of recovered control flow primitive of the old exploration;
+	// or empty if not present.
+	Old string
+	// Description of recovered control flow primitive of the new exploration;
+	// or empty if not present.
+	New string
+	// Specifies whether the control flow primitives differ.
+	Diverged bool
+}
+
+// compare compares two explorations of the same LLVM IR module, aligning the
+// steps of each function present in both explorations.
+//
+// - oldDir is the explore output directory of the old exploration.
+//
+// - newDir is the explore output directory of the new exploration.
+//
+// - force specifies whether to force overwrite the existing output directory.
+func (e *explorer) compare(oldDir, newDir string, force bool) error {
+	oldSummaries, err := parseSummaries(oldDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	newSummaries, err := parseSummaries(newDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(oldSummaries) == 0 {
+		return errors.Errorf("no function summaries found in %q; re-run explore to generate them", oldDir)
+	}
+	if len(newSummaries) == 0 {
+		return errors.Errorf("no function summaries found in %q; re-run explore to generate them", newDir)
+	}
+	// Initialize visualization, create output directory, parse template assets,
+	// and copy styles.
+	if err := e.init(force); err != nil {
+		return errors.WithStack(err)
+	}
+	// Compare functions present in either exploration.
+	nameSet := make(map[string]bool)
+	for name := range oldSummaries {
+		nameSet[name] = true
+	}
+	for name := range newSummaries {
+		nameSet[name] = true
+	}
+	var names []string
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	e.slugs = funcSlugs(names)
+	var comps []*funcComparison
+	for _, name := range names {
+		oldSummary, inOld := oldSummaries[name]
+		newSummary, inNew := newSummaries[name]
+		switch {
+		case !inNew:
+			comps = append(comps, &funcComparison{Name: displayName(name), Status: fmt.Sprintf("only in %s", oldDir)})
+		case !inOld:
+			comps = append(comps, &funcComparison{Name: displayName(name), Status: fmt.Sprintf("only in %s", newDir)})
+		default:
+			comp := compareFunc(oldSummary, newSummary)
+			comp.Link = e.paths(name).comparePage()
+			if err := e.outputCompare(comp, oldDir, newDir); err != nil {
+				return errors.WithStack(err)
+			}
+			comps = append(comps, comp)
+		}
+	}
+	return e.outputCompareIndex(comps, oldDir, newDir)
+}
+
+// compareFunc compares two explorations of the same function.
+func compareFunc(oldSummary, newSummary *funcSummary) *funcComparison {
+	comp := &funcComparison{
+		Name: displayName(oldSummary.Name),
+	}
+	nsteps := len(oldSummary.Prims)
+	if len(newSummary.Prims) > nsteps {
+		nsteps = len(newSummary.Prims)
+	}
+	for i := 0; i < nsteps; i++ {
+		var oldPrim, newPrim *primitive.Primitive
+		s := stepComparison{Step: i + 1}
+		if i < len(oldSummary.Prims) {
+			oldPrim = oldSummary.Prims[i]
+			s.Old = primDesc(oldPrim)
+		}
+		if i < len(newSummary.Prims) {
+			newPrim = newSummary.Prims[i]
+			s.New = primDesc(newPrim)
+		}
+		s.Diverged = !reflect.DeepEqual(oldPrim, newPrim)
+		if s.Diverged && comp.Divergence == 0 {
+			comp.Divergence = s.Step
+		}
+		comp.Steps = append(comp.Steps, s)
+	}
+	oldGo, newGo := oldSummary.finalGoSource(), newSummary.finalGoSource()
+	comp.GoRows = sideBySide(lineDiff(oldGo, newGo))
+	switch {
+	case comp.Divergence != 0:
+		comp.Status = fmt.Sprintf("diverges at step %d", comp.Divergence)
+	case oldGo != newGo:
+		comp.Status = "identical primitives, different Go output"
+	default:
+		comp.Status = "identical"
+	}
+	return comp
+}
+
+// parseCompareTemplates parses the HTML templates used to compare
+// explorations.
+func (e *explorer) parseCompareTemplates() error {
+	for _, tmplName := range []string{"compare.tmpl", "compare_index.tmpl"} {
+		tmplPath := filepath.Join(e.repoDir, "cmd/explore", tmplName)
+		ts, err := template.ParseFiles(tmplPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		switch tmplName {
+		case "compare.tmpl":
+			e.compareTmpl = ts.Lookup(tmplName)
+		case "compare_index.tmpl":
+			e.compareIndexTmpl = ts.Lookup(tmplName)
+		}
+	}
+	return nil
+}
+
+// outputCompare outputs the comparison of two explorations of a function.
+//
+// - comp is the comparison of the function.
+//
+// - oldDir is the explore output directory of the old exploration.
+//
+// - newDir is the explore output directory of the new exploration.
+func (e *explorer) outputCompare(comp *funcComparison, oldDir, newDir string) error {
+	htmlContent := &bytes.Buffer{}
+	data := map[string]interface{}{
+		"Comp":   comp,
+		"OldDir": oldDir,
+		"NewDir": newDir,
+	}
+	if err := e.compareTmpl.Execute(htmlContent, data); err != nil {
+		return errors.WithStack(err)
+	}
+	htmlPath := filepath.Join(e.outputDir, comp.Link)
+	dbg.Printf("creating file %q", htmlPath)
+	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// outputCompareIndex outputs the index page of the comparison of two
+// explorations.
+//
+// - comps is the comparison of each function.
+//
+// - oldDir is the explore output directory of the old exploration.
+//
+// - newDir is the explore output directory of the new exploration.
+func (e *explorer) outputCompareIndex(comps []*funcComparison, oldDir, newDir string) error {
+	htmlContent := &bytes.Buffer{}
+	data := map[string]interface{}{
+		"Comps":  comps,
+		"OldDir": oldDir,
+		"NewDir": newDir,
+	}
+	if err := e.compareIndexTmpl.Execute(htmlContent, data); err != nil {
+		return errors.WithStack(err)
+	}
+	htmlPath := filepath.Join(e.outputDir, "index.html")
+	dbg.Printf("creating file %q", htmlPath)
+	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
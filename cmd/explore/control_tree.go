@@ -0,0 +1,96 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/llir/llvm/ir"
+	"github.com/mewmew/lnp/pkg/cfa/primitive"
+)
+
+// controlNode is a node of the control tree of a function, which represents
+// either an original basic block (leaf) or a recovered control flow primitive
+// with the merged nodes as children.
+type controlNode struct {
+	// Node ID; pre-order index within the control tree.
+	ID int
+	// Role of the node within the parent primitive (e.g. "cond"); or empty if
+	// root node.
+	Role string
+	// Primitive name (e.g. "if"); or empty if basic block.
+	Prim string
+	// Node name; the basic block name, or the name of the entry node if
+	// primitive.
+	Name string
+	// Step of the control flow analysis (1-based) in which the primitive was
+	// recovered; or 0 if basic block.
+	Step int
+	// Link to the overview page of the step in which the primitive was
+	// recovered; or empty if basic block.
+	Link string
+	// Names of the original basic blocks contained within the node.
+	Blocks []string
+	// Child nodes, sorted by role.
+	Children []*controlNode
+}
+
+// controlTree returns the control tree of the given function, as formed by
+// merging the nodes of the recovered control flow primitives in order. The
+// root nodes are sorted in basic block order of their entry node; a single root
+// node is returned if the control flow recovery was complete.
+func controlTree(f *ir.Func, prims []*primitive.Primitive) []*controlNode {
+	nodes := make(map[string]*controlNode)
+	for _, block := range f.Blocks {
+		name := block.Name()
+		nodes[name] = &controlNode{Name: name, Blocks: []string{name}}
+	}
+	for i, prim := range prims {
+		parent := &controlNode{Prim: prim.Prim, Name: prim.Entry, Step: i + 1}
+		var roles []string
+		for role := range prim.Nodes {
+			roles = append(roles, role)
+		}
+		sort.Strings(roles)
+		for _, role := range roles {
+			name := prim.Nodes[role]
+			child, ok := nodes[name]
+			if !ok {
+				// Unknown node name; ignore.
+				continue
+			}
+			child.Role = role
+			parent.Blocks = append(parent.Blocks, child.Blocks...)
+			parent.Children = append(parent.Children, child)
+			delete(nodes, name)
+		}
+		nodes[prim.Entry] = parent
+	}
+	// Sort root nodes in basic block order.
+	var roots []*controlNode
+	for _, block := range f.Blocks {
+		if root, ok := nodes[block.Name()]; ok {
+			roots = append(roots, root)
+		}
+	}
+	// Assign node IDs in pre-order.
+	id := 0
+	var number func(n *controlNode)
+	number = func(n *controlNode) {
+		n.ID = id
+		id++
+		for _, child := range n.Children {
+			number(child)
+		}
+	}
+	for _, root := range roots {
+		number(root)
+	}
+	return roots
+}
+
+// walk invokes visit on n and its descendants in pre-order.
+func (n *controlNode) walk(visit func(n *controlNode)) {
+	visit(n)
+	for _, child := range n.Children {
+		child.walk(visit)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"github.com/pkg/errors"
+)
+
+// valueRef is a reference to a line of the LLVM IR assembly of a function.
+type valueRef struct {
+	// Line number (1-based) in the LLVM IR assembly of the function.
+	Line int `json:"line"`
+	// Name of the basic block containing the line; or empty if function
+	// header.
+	Block string `json:"block"`
+}
+
+// defUse is the def-use chain of a local SSA value.
+type defUse struct {
+	// Definition of the value.
+	Def valueRef `json:"def"`
+	// Uses of the value.
+	Uses []valueRef `json:"uses"`
+}
+
+// defUseChains returns the def-use chains of the local SSA values (function
+// parameters, basic blocks and value instructions) of the given function;
+// indexed by local identifier (e.g. "%x"). Line numbers refer to the LLVM IR
+// assembly of f, as printed by l.
+func defUseChains(f *ir.Func, l *funcListing) (map[string]*defUse, error) {
+	chains := make(map[string]*defUse)
+	// Function parameters are defined in the function header.
+	for _, param := range f.Params {
+		chains[param.Ident()] = &defUse{Def: valueRef{Line: 1}}
+	}
+	// Locate definitions.
+	type line struct {
+		ref  valueRef
+		def  string
+		inst ir.LLStringer
+	}
+	var lines []line
+	for _, block := range f.Blocks {
+		blockName := block.Name()
+		// The first line of the basic block contains its label.
+		start := l.blocks[block][0]
+		chains[block.Ident()] = &defUse{Def: valueRef{Line: start, Block: blockName}}
+		insts := make([]ir.LLStringer, 0, len(block.Insts)+1)
+		for _, inst := range block.Insts {
+			insts = append(insts, inst)
+		}
+		insts = append(insts, block.Term)
+		for _, inst := range insts {
+			ln := line{ref: valueRef{Line: l.insts[inst][0], Block: blockName}, inst: inst}
+			if ident, ok := localDef(inst); ok {
+				ln.def = ident
+				chains[ident] = &defUse{Def: ln.ref}
+			}
+			lines = append(lines, ln)
+		}
+	}
+	// Locate uses.
+	for _, ln := range lines {
+		ops, err := operands(ln.inst)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		seen := make(map[string]bool)
+		for _, v := range ops {
+			// Metadata arguments (e.g. of llvm.dbg.value) may wrap local values.
+			if md, ok := v.(*metadata.Value); ok {
+				mv, ok := md.Value.(value.Value)
+				if !ok {
+					continue
+				}
+				v = mv
+			}
+			n, ok := v.(value.Named)
+			if !ok {
+				continue
+			}
+			ident := n.Ident()
+			chain, ok := chains[ident]
+			if !ok || seen[ident] {
+				continue
+			}
+			seen[ident] = true
+			chain.Uses = append(chain.Uses, ln.ref)
+		}
+	}
+	return chains, nil
+}
+
+// operands returns the operands of the given instruction or terminator,
+// including target basic blocks. Operands may be nil (e.g. the return value of
+// a void return).
+func operands(inst ir.LLStringer) ([]value.Value, error) {
+	switch inst := inst.(type) {
+	// Binary instructions.
+	case *ir.InstAdd:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstFAdd:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstSub:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstFSub:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstMul:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstFMul:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstUDiv:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstSDiv:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstFDiv:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstURem:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstSRem:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstFRem:
+		return []value.Value{inst.X, inst.Y}, nil
+	// Bitwise instructions.
+	case *ir.InstShl:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstLShr:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstAShr:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstAnd:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstOr:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstXor:
+		return []value.Value{inst.X, inst.Y}, nil
+	// Vector instructions.
+	case *ir.InstExtractElement:
+		return []value.Value{inst.X, inst.Index}, nil
+	case *ir.InstInsertElement:
+		return []value.Value{inst.X, inst.Elem, inst.Index}, nil
+	case *ir.InstShuffleVector:
+		return []value.Value{inst.X, inst.Y, inst.Mask}, nil
+	// Aggregate instructions.
+	case *ir.InstExtractValue:
+		return []value.Value{inst.X}, nil
+	case *ir.InstInsertValue:
+		return []value.Value{inst.X, inst.Elem}, nil
+	// Memory instructions.
+	case *ir.InstAlloca:
+		return []value.Value{inst.NElems}, nil
+	case *ir.InstLoad:
+		return []value.Value{inst.Src}, nil
+	case *ir.InstStore:
+		return []value.Value{inst.Src, inst.Dst}, nil
+	case *ir.InstFence:
+		return nil, nil
+	case *ir.InstCmpXchg:
+		return []value.Value{inst.Ptr, inst.Cmp, inst.New}, nil
+	case *ir.InstAtomicRMW:
+		return []value.Value{inst.Dst, inst.X}, nil
+	case *ir.InstGetElementPtr:
+		return append([]value.Value{inst.Src}, inst.Indices...), nil
+	// Conversion instructions.
+	case *ir.InstTrunc:
+		return []value.Value{inst.From}, nil
+	case *ir.InstZExt:
+		return []value.Value{inst.From}, nil
+	case *ir.InstSExt:
+		return []value.Value{inst.From}, nil
+	case *ir.InstFPTrunc:
+		return []value.Value{inst.From}, nil
+	case *ir.InstFPExt:
+		return []value.Value{inst.From}, nil
+	case *ir.InstFPToUI:
+		return []value.Value{inst.From}, nil
+	case *ir.InstFPToSI:
+		return []value.Value{inst.From}, nil
+	case *ir.InstUIToFP:
+		return []value.Value{inst.From}, nil
+	case *ir.InstSIToFP:
+		return []value.Value{inst.From}, nil
+	case *ir.InstPtrToInt:
+		return []value.Value{inst.From}, nil
+	case *ir.InstIntToPtr:
+		return []value.Value{inst.From}, nil
+	case *ir.InstBitCast:
+		return []value.Value{inst.From}, nil
+	case *ir.InstAddrSpaceCast:
+		return []value.Value{inst.From}, nil
+	// Other instructions.
+	case *ir.InstICmp:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstFCmp:
+		return []value.Value{inst.X, inst.Y}, nil
+	case *ir.InstPhi:
+		var ops []value.Value
+		for _, inc := range inst.Incs {
+			ops = append(ops, inc.X, inc.Pred)
+		}
+		return ops, nil
+	case *ir.InstSelect:
+		return []value.Value{inst.Cond, inst.X, inst.Y}, nil
+	case *ir.InstCall:
+		ops := append([]value.Value{inst.Callee}, inst.Args...)
+		for _, bundle := range inst.OperandBundles {
+			ops = append(ops, bundle.Inputs...)
+		}
+		return ops, nil
+	case *ir.InstVAArg:
+		return []value.Value{inst.ArgList}, nil
+	case *ir.InstLandingPad:
+		var ops []value.Value
+		for _, clause := range inst.Clauses {
+			ops = append(ops, clause.X)
+		}
+		return ops, nil
+	case *ir.InstCatchPad:
+		return append([]value.Value{inst.Scope}, inst.Args...), nil
+	case *ir.InstCleanupPad:
+		return append([]value.Value{inst.Scope}, inst.Args...), nil
+	// Terminators.
+	case *ir.TermRet:
+		return []value.Value{inst.X}, nil
+	case *ir.TermBr:
+		return []value.Value{inst.Target}, nil
+	case *ir.TermCondBr:
+		return []value.Value{inst.Cond, inst.TargetTrue, inst.TargetFalse}, nil
+	case *ir.TermSwitch:
+		ops := []value.Value{inst.X, inst.TargetDefault}
+		for _, c := range inst.Cases {
+			ops = append(ops, c.X, c.Target)
+		}
+		return ops, nil
+	case *ir.TermIndirectBr:
+		ops := []value.Value{inst.Addr}
+		for _, target := range inst.ValidTargets {
+			ops = append(ops, target)
+		}
+		return ops, nil
+	case *ir.TermInvoke:
+		ops := append([]value.Value{inst.Invokee}, inst.Args...)
+		for _, bundle := range inst.OperandBundles {
+			ops = append(ops, bundle.Inputs...)
+		}
+		return append(ops, inst.Normal, inst.Exception), nil
+	case *ir.TermResume:
+		return []value.Value{inst.X}, nil
+	case *ir.TermCatchSwitch:
+		ops := []value.Value{inst.Scope}
+		for _, handler := range inst.Handlers {
+			ops = append(ops, handler)
+		}
+		if target, ok := inst.UnwindTarget.(*ir.Block); ok {
+			ops = append(ops, target)
+		}
+		return ops, nil
+	case *ir.TermCatchRet:
+		return []value.Value{inst.From, inst.To}, nil
+	case *ir.TermCleanupRet:
+		ops := []value.Value{inst.From}
+		if target, ok := inst.UnwindTarget.(*ir.Block); ok {
+			ops = append(ops, target)
+		}
+		return ops, nil
+	case *ir.TermUnreachable:
+		return nil, nil
+	default:
+		return nil, errors.Errorf("support for instruction %T not yet implemented", inst)
+	}
+}
+
+// localDef returns the local identifier defined by the given instruction or
+// terminator. The boolean return value indicates whether the instruction
+// produces a (non-void) value.
+func localDef(inst interface{}) (string, bool) {
+	n, ok := inst.(value.Named)
+	if !ok || n.Type().Equal(types.Void) {
+		return "", false
+	}
+	return n.Ident(), true
+}
@@ -0,0 +1,133 @@
+package main
+
+import "fmt"
+
+// Edge kinds of a depth-first search.
+const (
+	// Edge to a node first discovered through the edge.
+	edgeTree = "tree"
+	// Edge to an ancestor in the DFS spanning tree (or self loop).
+	edgeBack = "back"
+	// Edge to a proper descendant in the DFS spanning tree, which is not a tree
+	// edge.
+	edgeForward = "forward"
+	// Edge to a node in a different subtree, which has already been finished.
+	edgeCross = "cross"
+)
+
+// dfsTree is the depth-first search spanning tree of a control flow graph,
+// rooted at the entry node. Successors are visited in the order of the
+// terminator of each basic block.
+type dfsTree struct {
+	// Preorder number (1-based) of each node; or 0 if unreachable from the
+	// entry node. Indexed by node.
+	pre []int
+	// Postorder number (1-based) of each node; or 0 if unreachable.
+	post []int
+	// Reverse postorder number (1-based) of each node; or 0 if unreachable.
+	rpo []int
+	// Parent of each node in the DFS spanning tree; or -1 if root or
+	// unreachable.
+	parent []int
+	// Kind of each edge reachable from the entry node; indexed by from and to
+	// node.
+	edgeKinds map[[2]int]string
+	// Events of the depth-first search, in order.
+	events []dfsEvent
+}
+
+// dfsEvent is an event of the depth-first search, used to animate the search.
+type dfsEvent struct {
+	// Event kind; one of "visit", "edge" and "finish".
+	Kind string `json:"kind"`
+	// Node visited or finished; or source node of the examined edge.
+	From int `json:"from"`
+	// Target node of the examined edge; or -1 if not present.
+	To int `json:"to"`
+	// Kind of the examined edge; or empty if not present.
+	EdgeKind string `json:"edge_kind,omitempty"`
+	// Human-readable description of the event.
+	Desc string `json:"desc"`
+}
+
+// depthFirst performs a depth-first search of the given control flow graph
+// from the entry node, numbering nodes and classifying edges.
+//
+// An edge u -> v is classified when examined from u as a tree edge if v has not
+// yet been visited, a back edge if v is on the DFS stack (i.e. visited but not
+// finished), a forward edge if v has been finished and was visited after u,
+// and a cross edge otherwise.
+func depthFirst(g *cfg) *dfsTree {
+	n := len(g.names)
+	t := &dfsTree{
+		pre:       make([]int, n),
+		post:      make([]int, n),
+		rpo:       make([]int, n),
+		parent:    make([]int, n),
+		edgeKinds: make(map[[2]int]string),
+	}
+	for i := range t.parent {
+		t.parent[i] = -1
+	}
+	if n == 0 {
+		return t
+	}
+	// DFS stack frame; node and index of next successor to examine.
+	type frame struct {
+		node, next int
+	}
+	preNum, postNum := 0, 0
+	visit := func(node int) {
+		preNum++
+		t.pre[node] = preNum
+		desc := fmt.Sprintf("visit %%%s; preorder number %d", g.names[node], preNum)
+		if parent := t.parent[node]; parent != -1 {
+			desc += fmt.Sprintf(" (child of %%%s)", g.names[parent])
+		}
+		t.events = append(t.events, dfsEvent{Kind: "visit", From: node, To: -1, Desc: desc})
+	}
+	const entry = 0
+	visit(entry)
+	stack := []*frame{{node: entry}}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		u := top.node
+		if top.next < len(g.succs[u]) {
+			v := g.succs[u][top.next]
+			top.next++
+			var kind, reason string
+			switch {
+			case t.pre[v] == 0:
+				kind, reason = edgeTree, fmt.Sprintf("%%%s has not been visited", g.names[v])
+			case t.post[v] == 0:
+				kind, reason = edgeBack, fmt.Sprintf("%%%s is on the DFS stack", g.names[v])
+			case t.pre[u] < t.pre[v]:
+				kind, reason = edgeForward, fmt.Sprintf("%%%s is a finished descendant of %%%s", g.names[v], g.names[u])
+			default:
+				kind, reason = edgeCross, fmt.Sprintf("%%%s was finished in another subtree", g.names[v])
+			}
+			t.edgeKinds[[2]int{u, v}] = kind
+			desc := fmt.Sprintf("examine edge %%%s → %%%s; %s edge, since %s", g.names[u], g.names[v], kind, reason)
+			t.events = append(t.events, dfsEvent{Kind: "edge", From: u, To: v, EdgeKind: kind, Desc: desc})
+			if kind == edgeTree {
+				t.parent[v] = u
+				visit(v)
+				stack = append(stack, &frame{node: v})
+			}
+			continue
+		}
+		// All successors examined; finish node.
+		stack = stack[:len(stack)-1]
+		postNum++
+		t.post[u] = postNum
+		desc := fmt.Sprintf("finish %%%s; postorder number %d", g.names[u], postNum)
+		t.events = append(t.events, dfsEvent{Kind: "finish", From: u, To: -1, Desc: desc})
+	}
+	// Reverse postorder numbers are assigned in decreasing postorder.
+	for node, post := range t.post {
+		if post != 0 {
+			t.rpo[node] = postNum - post + 1
+		}
+	}
+	return t
+}
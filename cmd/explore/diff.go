@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffLine is a line of a line-based diff.
+type diffLine struct {
+	// Kind of diff line; "eq" (unchanged), "del" (removed) or "ins" (added).
+	Kind string
+	// Line number (1-based) in the old text; or 0 if added line.
+	OldLine int
+	// Line number (1-based) in the new text; or 0 if removed line.
+	NewLine int
+	// Contents of line.
+	Text string
+}
+
+// diffHunk is a hunk of a unified diff.
+type diffHunk struct {
+	// Hunk header; e.g. "@@ -1,4 +1,5 @@".
+	Header string
+	// Lines of the hunk.
+	Lines []diffLine
+}
+
+// diffRow is a row of a side-by-side diff.
+type diffRow struct {
+	// Line of the old text; or nil if not present.
+	Old *diffLine
+	// Line of the new text; or nil if not present.
+	New *diffLine
+}
+
+// lineDiff returns the line-based diff between the old and the new text, based
+// on the longest common subsequence of lines.
+func lineDiff(oldText, newText string) []diffLine {
+	a, b := splitLines(oldText), splitLines(newText)
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and
+	// b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{Kind: "eq", OldLine: i + 1, NewLine: j + 1, Text: a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{Kind: "del", OldLine: i + 1, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{Kind: "ins", NewLine: j + 1, Text: b[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// hasChanges reports whether the given diff contains added or removed lines.
+func hasChanges(lines []diffLine) bool {
+	for _, line := range lines {
+		if line.Kind != "eq" {
+			return true
+		}
+	}
+	return false
+}
+
+// unifiedHunks groups the changed lines of the given diff into hunks of a
+// unified diff, with the specified number of unchanged lines of context.
+func unifiedHunks(lines []diffLine, context int) []diffHunk {
+	var hunks []diffHunk
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == "eq" {
+			i++
+			continue
+		}
+		// Extend hunk for as long as changes are within 2*context lines of each
+		// other.
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].Kind != "eq" {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].Kind == "eq" {
+				next++
+			}
+			if next == len(lines) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+		end += context
+		if end > len(lines) {
+			end = len(lines)
+		}
+		hunk := diffHunk{Lines: lines[start:end]}
+		hunk.Header = hunkHeader(hunk.Lines)
+		hunks = append(hunks, hunk)
+		i = end
+	}
+	return hunks
+}
+
+// hunkHeader returns the header of the unified diff hunk with the given lines.
+func hunkHeader(lines []diffLine) string {
+	oldStart, newStart := 0, 0
+	oldLen, newLen := 0, 0
+	for _, line := range lines {
+		if line.OldLine != 0 {
+			if oldStart == 0 {
+				oldStart = line.OldLine
+			}
+			oldLen++
+		}
+		if line.NewLine != 0 {
+			if newStart == 0 {
+				newStart = line.NewLine
+			}
+			newLen++
+		}
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldLen, newStart, newLen)
+}
+
+// sideBySide returns the rows of a side-by-side diff of the given diff, pairing
+// up removed and added lines of each change.
+func sideBySide(lines []diffLine) []diffRow {
+	var rows []diffRow
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == "eq" {
+			rows = append(rows, diffRow{Old: &lines[i], New: &lines[i]})
+			i++
+			continue
+		}
+		var dels, inss []*diffLine
+		for ; i < len(lines) && lines[i].Kind != "eq"; i++ {
+			if lines[i].Kind == "del" {
+				dels = append(dels, &lines[i])
+			} else {
+				inss = append(inss, &lines[i])
+			}
+		}
+		for j := 0; j < len(dels) || j < len(inss); j++ {
+			var row diffRow
+			if j < len(dels) {
+				row.Old = dels[j]
+			}
+			if j < len(inss) {
+				row.New = inss[j]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// splitLines splits the given text into lines, ignoring the trailing newline.
+func splitLines(s string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
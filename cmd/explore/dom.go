@@ -0,0 +1,145 @@
+package main
+
+// domTree is a dominator tree (or post-dominator tree) of a control flow graph.
+type domTree struct {
+	// Root node of the tree.
+	root int
+	// Immediate dominator of each node; indexed by node. The immediate
+	// dominator of the root node and of nodes unreachable from the root node is
+	// -1.
+	idom []int
+}
+
+// dominators returns the dominator tree of the given control flow graph, rooted
+// at the entry node.
+func dominators(g *cfg) *domTree {
+	const entry = 0
+	return &domTree{
+		root: entry,
+		idom: computeIdoms(entry, g.succs, g.preds),
+	}
+}
+
+// postDominators returns the post-dominator tree of the given control flow
+// graph. The tree is rooted at a virtual exit node (with node index
+// len(g.names)), which succeeds every node without successors in g.
+func postDominators(g *cfg) *domTree {
+	// Compute post-dominators as the dominators of the reverse control flow
+	// graph, extended with a virtual exit node.
+	exit := len(g.names)
+	succs := make([][]int, exit+1)
+	preds := make([][]int, exit+1)
+	for i := range g.names {
+		succs[i] = g.preds[i]
+		preds[i] = g.succs[i]
+		if len(g.succs[i]) == 0 {
+			succs[exit] = append(succs[exit], i)
+			preds[i] = append(preds[i], exit)
+		}
+	}
+	return &domTree{
+		root: exit,
+		idom: computeIdoms(exit, succs, preds),
+	}
+}
+
+// dominates reports whether node a dominates node b.
+func (t *domTree) dominates(a, b int) bool {
+	for n := b; n != -1; n = t.idom[n] {
+		if n == a {
+			return true
+		}
+	}
+	return false
+}
+
+// children returns the children of each node in the dominator tree; indexed by
+// node.
+func (t *domTree) children() [][]int {
+	children := make([][]int, len(t.idom))
+	for n, idom := range t.idom {
+		if idom != -1 {
+			children[idom] = append(children[idom], n)
+		}
+	}
+	return children
+}
+
+// computeIdoms returns the immediate dominator of each node in the graph
+// specified by the given successor and predecessor lists, using the iterative
+// algorithm of Cooper, Harvey and Kennedy [1].
+//
+// [1]: http://www.hipersoft.rice.edu/grads/publications/dom14.pdf
+func computeIdoms(root int, succs, preds [][]int) []int {
+	// Number nodes in reverse postorder.
+	order := reversePostorder(root, succs)
+	rpo := make([]int, len(succs))
+	for i := range rpo {
+		rpo[i] = -1
+	}
+	for i, n := range order {
+		rpo[n] = i
+	}
+	idom := make([]int, len(succs))
+	for i := range idom {
+		idom[i] = -1
+	}
+	idom[root] = root
+	intersect := func(a, b int) int {
+		for a != b {
+			for rpo[a] > rpo[b] {
+				a = idom[a]
+			}
+			for rpo[b] > rpo[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, n := range order[1:] {
+			newIdom := -1
+			for _, pred := range preds[n] {
+				if idom[pred] == -1 {
+					// Skip unprocessed and unreachable predecessors.
+					continue
+				}
+				if newIdom == -1 {
+					newIdom = pred
+				} else {
+					newIdom = intersect(pred, newIdom)
+				}
+			}
+			if idom[n] != newIdom {
+				idom[n] = newIdom
+				changed = true
+			}
+		}
+	}
+	idom[root] = -1
+	return idom
+}
+
+// reversePostorder returns the nodes reachable from root in reverse postorder
+// of a depth-first search.
+func reversePostorder(root int, succs [][]int) []int {
+	visited := make([]bool, len(succs))
+	var post []int
+	var visit func(n int)
+	visit = func(n int) {
+		visited[n] = true
+		for _, succ := range succs[n] {
+			if !visited[succ] {
+				visit(succ)
+			}
+		}
+		post = append(post, n)
+	}
+	visit(root)
+	order := make([]int, len(post))
+	for i, n := range post {
+		order[len(post)-1-i] = n
+	}
+	return order
+}
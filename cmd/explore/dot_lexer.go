@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/alecthomas/chroma"
+)
+
+// dotLexer is a Chroma lexer for the DOT graph description language of
+// Graphviz, which is not provided by Chroma.
+var dotLexer = chroma.MustNewLexer(
+	&chroma.Config{
+		Name:      "DOT",
+		Aliases:   []string{"dot", "graphviz"},
+		Filenames: []string{"*.dot", "*.gv"},
+	},
+	chroma.Rules{
+		"root": {
+			{Pattern: `\s+`, Type: chroma.Text},
+			{Pattern: `//.*?$`, Type: chroma.CommentSingle},
+			{Pattern: `^#.*?$`, Type: chroma.CommentPreproc},
+			{Pattern: `/\*(.|\n)*?\*/`, Type: chroma.CommentMultiline},
+			{Pattern: `(?i)\b(strict|graph|digraph|subgraph|node|edge)\b`, Type: chroma.Keyword},
+			{Pattern: `(->|--)`, Type: chroma.Operator},
+			{Pattern: `([A-Za-z_\x80-\xff][\w\x80-\xff]*)(\s*)(=)`, Type: chroma.ByGroups(chroma.NameAttribute, chroma.Text, chroma.Operator)},
+			{Pattern: `[A-Za-z_\x80-\xff][\w\x80-\xff]*`, Type: chroma.NameVariable},
+			{Pattern: `-?(\.\d+|\d+(\.\d*)?)`, Type: chroma.LiteralNumber},
+			{Pattern: `"(\\\\|\\"|[^"])*"`, Type: chroma.LiteralStringDouble},
+			{Pattern: `<`, Type: chroma.LiteralString, Mutator: chroma.Push("html")},
+			{Pattern: `[{}\[\];:,=]`, Type: chroma.Punctuation},
+		},
+		"html": {
+			{Pattern: `<`, Type: chroma.LiteralString, Mutator: chroma.Push()},
+			{Pattern: `>`, Type: chroma.LiteralString, Mutator: chroma.Pop(1)},
+			{Pattern: `[^<>]+`, Type: chroma.LiteralString},
+		},
+	},
+)
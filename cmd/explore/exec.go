@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/pkg/errors"
+)
+
+func execUsage() {
+	const use = `
+Execute a function of an LLVM IR module on concrete argument values, and replay
+the executed basic block trace.
+
+Usage:
+
+	explore exec [OPTION]... FILE.ll FUNC [ARG]...
+
+Arguments are integer literals (e.g. 42, -1 or 0x2A), or null for pointer
+parameters.
+
+The replay is added to the existing exploration of the LLVM IR module, which is
+explored first if not present.
+
+Flags:
+`
+	fmt.Fprintln(os.Stderr, use[1:])
+}
+
+// execMain executes a function on concrete argument values and outputs a
+// replay of the executed basic block trace, as specified by the command line
+// arguments of the `explore exec` command.
+func execMain(args []string) {
+	// Parse command line arguments.
+	var (
+		// fullModule specifies whether to present the LLVM IR assembly of the
+		// whole module in the LLVM pane.
+		fullModule bool
+		// maxSteps specifies the maximum number of executed basic blocks.
+		maxSteps int
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+		// style specifies the style used for syntax highlighting.
+		style string
+	)
+	fs := flag.NewFlagSet("exec", flag.ExitOnError)
+	fs.IntVar(&maxSteps, "max-steps", 10000, "maximum number of executed basic blocks")
+	fs.BoolVar(&fullModule, "module", false, "present LLVM IR assembly of the whole module in the LLVM pane (as used by the exploration)")
+	fs.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	fs.StringVar(&style, "style", "vs", "style used for syntax highlighting (borland, monokai, vs, ...)")
+	fs.Usage = func() {
+		execUsage()
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	llPath, funcName, funcArgs := fs.Arg(0), fs.Arg(1), fs.Args()[2:]
+	if quiet {
+		// Mute debug messages if `-q` is set.
+		dbg.SetOutput(ioutil.Discard)
+	}
+	if err := execFile(llPath, funcName, funcArgs, style, fullModule, maxSteps); err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
+
+// execFile executes the given function of the LLVM IR assembly file on concrete
+// argument values, and adds a replay of the executed basic block trace to the
+// exploration of the module. The module is explored first if not present; an
+// existing exploration is never overwritten.
+//
+// - llPath is the path to the LLVM IR assembly file.
+//
+// - funcName is the function name of the executed function.
+//
+// - args are the argument values of the function.
+//
+// - style is the style used for syntax highlighting.
+//
+// - fullModule specifies whether to present the LLVM IR assembly of the whole
+//   module in the LLVM pane, as used by the exploration.
+//
+// - maxSteps is the maximum number of executed basic blocks.
+func execFile(llPath, funcName string, args []string, style string, fullModule bool, maxSteps int) error {
+	e, err := loadModule(llPath, style, fullModule)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := findFunc(e.m, funcName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(f.Blocks) == 0 {
+		return errors.Errorf("unable to execute function declaration %q", funcName)
+	}
+	p := e.paths(funcName)
+	if osutil.Exists(e.outputDir) {
+		// Add to existing exploration.
+		if !osutil.Exists(p.path(p.overviewPage(1))) || !osutil.Exists(p.cfgDOT()) {
+			return errors.Errorf("function %q not present in exploration %q; explore it first (e.g. using -funcs)", funcName, e.outputDir)
+		}
+		if err := e.findRepoDir(); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := e.parseTemplates(); err != nil {
+			return errors.WithStack(err)
+		}
+	} else {
+		dbg.Printf("exploring module %q", llPath)
+		if err := e.explore(&funcFilter{}, false); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	dbg.Printf("executing function %q", funcName)
+	trace, err := execFunc(e.m, f, args, maxSteps)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	call := fmt.Sprintf("%s(%s)", displayName(funcName), strings.Join(args, ", "))
+	if len(trace.Err) > 0 {
+		warn.Printf("execution of %s terminated after %d basic blocks; %s", call, len(trace.Blocks), trace.Err)
+	} else {
+		dbg.Printf("executed %s in %d basic blocks; result %s", call, len(trace.Blocks), trace.Result)
+	}
+	if err := e.outputExec(f, trace); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -25,6 +25,17 @@ type explorer struct {
 	m *ir.Module
 	// Debug LLVM IR module (foo_dbg.ll); or nil if not present.
 	dbg *ir.Module
+	// Specifies whether to present the LLVM IR assembly of the whole module in
+	// the LLVM pane.
+	fullModule bool
+	// Minimum number of recovered control flow primitives of functions for
+	// which to skip the substeps before merge; or 0 to never skip.
+	skipBefore int
+	// Execution count of each basic block, as recorded by a profiling run; or
+	// nil if not present.
+	counts blockCounts
+	// LLVM IR assembly of the whole module; or nil if not yet generated.
+	modLLVM *moduleLLVM
 	// Base name (name of LLVM IR assembly file without extension).
 	base string
 	// Explore output directory.
@@ -33,6 +44,8 @@ type explorer struct {
 	dotDir string
 	// Chroma style name used for syntax highlighting.
 	style string
+	// Function name slugs used in output file names; indexed by function name.
+	slugs map[string]string
 	// Explore GitHub repository directory, from which HTML template assets are
 	// located.
 	repoDir string
@@ -46,6 +59,26 @@ type explorer struct {
 	cfaTmpl *template.Template
 	// Template for Go HTML page.
 	goTmpl *template.Template
+	// Template for dominator tree HTML page.
+	domTmpl *template.Template
+	// Template for control tree HTML page.
+	controlTreeTmpl *template.Template
+	// Template for depth-first search HTML page.
+	dfsTmpl *template.Template
+	// Template for interval analysis HTML page.
+	intervalsTmpl *template.Template
+	// Template for execution trace HTML page.
+	execTmpl *template.Template
+	// Template for index HTML page.
+	indexTmpl *template.Template
+	// Template for comparison HTML page of a function.
+	compareTmpl *template.Template
+	// Template for comparison index HTML page.
+	compareIndexTmpl *template.Template
+	// Template for optimization pipeline comparison HTML page of a function.
+	optTmpl *template.Template
+	// Template for optimization pipeline comparison index HTML page.
+	optIndexTmpl *template.Template
 }
 
 // newExplorer returns a new explorer which configures the output environment of
@@ -114,6 +147,25 @@ func (e *explorer) createOutputDir(force bool) error {
 	return nil
 }
 
+// createDOTDir creates the control flow graph directory based on the path of
+// the LLVM IR assembly file.
+//
+// For a source file "foo.ll" the control flow graph directory "foo_graphs/" is
+// created. If the `-force` flag is set, existing graph directories are
+// overwritten by force.
+func (e *explorer) createDOTDir(force bool) error {
+	if force {
+		// Force overwrite existing graph directories.
+		if err := os.RemoveAll(e.dotDir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := os.Mkdir(e.dotDir, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
 // findRepoDir locates the Explore GitHub repository directory.
 func (e *explorer) findRepoDir() error {
 	repoDir, err := goutil.SrcDir("github.com/mewmew/explore")
@@ -138,7 +190,31 @@ func (e *explorer) parseTemplates() error {
 	if err := e.parseCFATemplate(); err != nil {
 		return errors.WithStack(err)
 	}
-	return e.parseGoTemplate()
+	if err := e.parseGoTemplate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := e.parseDomTemplate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := e.parseControlTreeTemplate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := e.parseDFSTemplate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := e.parseIntervalsTemplate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := e.parseExecTemplate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := e.parseIndexTemplate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := e.parseCompareTemplates(); err != nil {
+		return errors.WithStack(err)
+	}
+	return e.parseOptTemplates()
 }
 
 // copyStyles copies the styles to the explore output directory.
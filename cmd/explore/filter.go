@@ -0,0 +1,158 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ianlancetaylor/demangle"
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// funcFilter specifies the set of functions for which to generate
+// visualizations.
+//
+// Patterns are either glob patterns (e.g. "foo*"), where '*' matches any
+// sequence of characters and '?' matches any single character, or regular
+// expressions prefixed with "re:" (e.g. "re:^_ZN3foo"). Glob patterns match the
+// entire function name, while regular expressions match any part of the
+// function name. A pattern matches a function if it matches either the
+// function name or the demangled function name of C++ and Rust functions.
+//
+// Patterns are separated by commas, except for commas within braces, brackets
+// or parentheses (e.g. "re:^a{1,3}$" or "foo(int, char)").
+type funcFilter struct {
+	// Include patterns; a function is included if it matches any include
+	// pattern. When empty, all functions are included.
+	include []*regexp.Regexp
+	// Exclude patterns; a function is excluded if it matches any exclude
+	// pattern.
+	exclude []*regexp.Regexp
+	// Minimum number of basic blocks.
+	minBlocks int
+}
+
+// newFuncFilter returns a new function filter based on the given
+// comma-separated lists of include and exclude patterns.
+//
+// - funcs is a comma-separated list of include patterns; or empty to include
+//   all functions.
+//
+// - exclude is a comma-separated list of exclude patterns.
+//
+// - minBlocks is the minimum number of basic blocks of included functions.
+func newFuncFilter(funcs, exclude string, minBlocks int) (*funcFilter, error) {
+	include, err := parsePatterns(funcs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	excl, err := parsePatterns(exclude)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	filter := &funcFilter{
+		include:   include,
+		exclude:   excl,
+		minBlocks: minBlocks,
+	}
+	return filter, nil
+}
+
+// parsePatterns parses the given comma-separated list of function name
+// patterns.
+func parsePatterns(patterns string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, pattern := range splitPatterns(patterns) {
+		pattern = strings.TrimSpace(pattern)
+		if len(pattern) == 0 {
+			continue
+		}
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// splitPatterns splits the given comma-separated list of function name
+// patterns. Commas within braces, brackets or parentheses, and escaped commas,
+// do not separate patterns.
+func splitPatterns(patterns string) []string {
+	var parts []string
+	start := 0
+	// Nesting depth of braces and parentheses.
+	depth := 0
+	// Within bracketed character class.
+	inClass := false
+	for i := 0; i < len(patterns); i++ {
+		c := patterns[i]
+		switch {
+		case c == '\\':
+			// Skip escaped character.
+			i++
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+		case c == '[':
+			inClass = true
+		case c == '{' || c == '(':
+			depth++
+		case c == '}' || c == ')':
+			if depth > 0 {
+				depth--
+			}
+		case c == ',' && depth == 0:
+			parts = append(parts, patterns[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, patterns[start:])
+}
+
+// compilePattern compiles the given function name pattern into a regular
+// expression.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "re:") {
+		re, err := regexp.Compile(pattern[len("re:"):])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid regular expression in function pattern %q", pattern)
+		}
+		return re, nil
+	}
+	// Translate glob pattern into anchored regular expression.
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.Replace(expr, `\*`, ".*", -1)
+	expr = strings.Replace(expr, `\?`, ".", -1)
+	return regexp.MustCompile("^" + expr + "$"), nil
+}
+
+// match reports whether the given function is included by the filter.
+func (filter *funcFilter) match(f *ir.Func) bool {
+	if len(f.Blocks) < filter.minBlocks {
+		return false
+	}
+	names := []string{f.Name()}
+	if demangled := demangle.Filter(f.Name()); demangled != f.Name() {
+		names = append(names, demangled)
+	}
+	if len(filter.include) > 0 && !matchAny(filter.include, names) {
+		return false
+	}
+	return !matchAny(filter.exclude, names)
+}
+
+// matchAny reports whether any of the given regular expressions matches any of
+// the given names.
+func matchAny(res []*regexp.Regexp, names []string) bool {
+	for _, re := range res {
+		for _, name := range names {
+			if re.MatchString(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
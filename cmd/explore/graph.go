@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/mewmew/lnp/pkg/cfa/primitive"
+	"github.com/pkg/errors"
+)
+
+// cfg is a control flow graph of a function, computed in-process from the
+// basic blocks and terminators of the parsed LLVM IR function. Nodes are
+// identified by the index of their corresponding basic block, and node 0 is the
+// entry node.
+type cfg struct {
+	// Function of the control flow graph.
+	f *ir.Func
+	// Node names (basic block names); indexed by node.
+	names []string
+	// Successors of each node; indexed by node.
+	succs [][]int
+	// Predecessors of each node; indexed by node.
+	preds [][]int
+	// Node index; indexed by node name.
+	index map[string]int
+}
+
+// newCFG returns the control flow graph of the given function.
+func newCFG(f *ir.Func) (*cfg, error) {
+	// Force generate local IDs.
+	if err := f.AssignIDs(); err != nil {
+		return nil, errors.Wrapf(err, "unable to assign IDs to local variables of function %q", f.Ident())
+	}
+	n := len(f.Blocks)
+	g := &cfg{
+		f:     f,
+		names: make([]string, n),
+		succs: make([][]int, n),
+		preds: make([][]int, n),
+		index: make(map[string]int),
+	}
+	for i, block := range f.Blocks {
+		name := block.Name()
+		g.names[i] = name
+		g.index[name] = i
+	}
+	for i, block := range f.Blocks {
+		// Note, a switch terminator may have several cases with the same target,
+		// only add one edge per target.
+		seen := make(map[int]bool)
+		for _, succ := range block.Term.Succs() {
+			j := g.index[succ.Name()]
+			if seen[j] {
+				continue
+			}
+			seen[j] = true
+			g.succs[i] = append(g.succs[i], j)
+			g.preds[j] = append(g.preds[j], i)
+		}
+	}
+	return g, nil
+}
+
+// nodes returns the node indices of the named nodes, in basic block order.
+// Unknown node names are ignored.
+func (g *cfg) nodes(names []string) []int {
+	set := make(map[int]bool)
+	for _, name := range names {
+		if i, ok := g.index[name]; ok {
+			set[i] = true
+		}
+	}
+	var nodes []int
+	for i := range g.names {
+		if set[i] {
+			nodes = append(nodes, i)
+		}
+	}
+	return nodes
+}
+
+// mergedBlocks returns the names of the original basic blocks contained within
+// each node of the control flow graph, after merging the nodes of the given
+// control flow primitives in order.
+//
+// Note, when merging the nodes of a control flow primitive, the new node
+// retains the name of the entry node of the primitive.
+func mergedBlocks(f *ir.Func, prims []*primitive.Primitive) map[string][]string {
+	blocks := make(map[string][]string)
+	for _, block := range f.Blocks {
+		name := block.Name()
+		blocks[name] = []string{name}
+	}
+	for _, prim := range prims {
+		var merged []string
+		for _, name := range sortedNodeNames(prim) {
+			merged = append(merged, blocks[name]...)
+			delete(blocks, name)
+		}
+		blocks[prim.Entry] = merged
+	}
+	return blocks
+}
+
+// primBlocks returns the names of the original basic blocks contained within
+// the nodes of the control flow primitive recovered in the given step.
+//
+// - prims is the list of recovered control flow primitives.
+//
+// - step is the intermediate step of the control flow analysis; step 0 has no
+//   associated control flow primitive.
+func primBlocks(f *ir.Func, prims []*primitive.Primitive, step int) []string {
+	if step == 0 {
+		return nil
+	}
+	blocks := mergedBlocks(f, prims[:step-1])
+	var names []string
+	for _, name := range sortedNodeNames(prims[step-1]) {
+		names = append(names, blocks[name]...)
+	}
+	return names
+}
+
+// sortedNodeNames returns the control flow graph node names of the given
+// primitive, sorted by role name to produce deterministic output.
+func sortedNodeNames(prim *primitive.Primitive) []string {
+	var roles []string
+	for role := range prim.Nodes {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	var names []string
+	for _, role := range roles {
+		names = append(names, prim.Nodes[role])
+	}
+	return names
+}
+
+// cfgDOT returns a representation of the given control flow graph in Graphviz
+// DOT format, as output by ll2dot and parsed by restructure. Nodes are
+// identified by basic block name, the entry node has the "entry" attribute, and
+// edges are labelled by branch condition.
+func cfgDOT(g *cfg) string {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "digraph %s {\n", dotQuote(g.f.Name()))
+	for n, name := range g.names {
+		fmt.Fprintf(buf, "\t%s", dotQuote(name))
+		if n == 0 {
+			buf.WriteString(" [entry=true]")
+		}
+		buf.WriteString("\n")
+	}
+	for from, succs := range g.succs {
+		for _, to := range succs {
+			fmt.Fprintf(buf, "\t%s -> %s", dotQuote(g.names[from]), dotQuote(g.names[to]))
+			fromBlock, toBlock := g.f.Blocks[from], g.f.Blocks[to]
+			if label, ok := edgeLabel(fromBlock, toBlock); ok {
+				fmt.Fprintf(buf, " [label=%s", dotQuote(label))
+				if color, ok := edgeColor(fromBlock, toBlock); ok {
+					fmt.Fprintf(buf, " color=%s", color)
+				}
+				buf.WriteString("]")
+			}
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// edgeLabel returns the label of the edge from the given basic block to the
+// given target, using the same labels as ll2dot for conditional branch and
+// switch terminators. The boolean return value indicates whether the edge has a
+// label.
+func edgeLabel(from, to *ir.Block) (string, bool) {
+	switch term := from.Term.(type) {
+	case *ir.TermCondBr:
+		if term.TargetTrue == term.TargetFalse {
+			return "", false
+		}
+		switch to {
+		case term.TargetTrue:
+			return "true", true
+		case term.TargetFalse:
+			return "false", true
+		}
+	case *ir.TermSwitch:
+		// Note, only one edge is added per target, so the label of the first
+		// case with the given target is used.
+		for _, c := range term.Cases {
+			if c.Target == to {
+				return fmt.Sprintf("case (x=%v)", c.X.Ident()), true
+			}
+		}
+		if term.TargetDefault == to {
+			return "default case", true
+		}
+	}
+	return "", false
+}
+
+// dotQuote returns the given string as a double-quoted DOT identifier.
+func dotQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/llir/llvm/asm"
 	"github.com/llir/llvm/ir"
@@ -27,8 +32,7 @@ func parseModule(llPath string) (*ir.Module, error) {
 // parsePrims parses the recovered control flow primitives of the given
 // function.
 func (e *explorer) parsePrims(funcName string) ([]*primitive.Primitive, error) {
-	jsonName := funcName + ".json"
-	jsonPath := filepath.Join(e.dotDir, jsonName)
+	jsonPath := e.paths(funcName).primsJSON()
 	var prims []*primitive.Primitive
 	if err := jsonutil.ParseFile(jsonPath, &prims); err != nil {
 		return nil, errors.WithStack(err)
@@ -58,21 +62,101 @@ func findBlock(f *ir.Func, blockName string) (*ir.Block, error) {
 	return nil, errors.Errorf("unable to locate basic block %q in function %q", blockName, f.Name())
 }
 
-// subStepFromPage returns the intermediate substep corresponding to the given
-// visualization page.
-func subStepFromPage(page int) string {
-	//    page 1: step 0
-	//    page 2: step 1a
-	//    page 3: step 1b
-	//    page 4: step 2a
-	//    page 5: step 2b
-	//    ...
-	switch {
-	case page == 1:
-		return ""
-	case page%2 == 0:
-		return "a"
+// stepPage specifies the intermediate step presented on an overview page.
+type stepPage struct {
+	// Intermediate step of the control flow analysis.
+	step int
+	// Intermediate substep; "a" before merge, "b" after merge, or empty on step
+	// 0.
+	subStep string
+}
+
+// stepPages returns the intermediate step presented on each overview page,
+// where index 0 corresponds to page 1.
+//
+//    page 1: step 0
+//    page 2: step 1a
+//    page 3: step 1b
+//    page 4: step 2a
+//    page 5: step 2b
+//    ...
+//
+// If skipBefore is set, the substeps before merge are omitted.
+//
+//    page 1: step 0
+//    page 2: step 1b
+//    page 3: step 2b
+//    ...
+//
+// - nsteps is the number of recovered control flow primitives.
+func stepPages(nsteps int, skipBefore bool) []stepPage {
+	pages := []stepPage{{step: 0}}
+	for step := 1; step <= nsteps; step++ {
+		if !skipBefore {
+			pages = append(pages, stepPage{step: step, subStep: "a"})
+		}
+		pages = append(pages, stepPage{step: step, subStep: "b"})
+	}
+	return pages
+}
+
+// stepPrims returns the control flow primitives merged in the given
+// intermediate step and substep.
+//
+// - prims is the list of recovered control flow primitives.
+func stepPrims(prims []*primitive.Primitive, step int, subStep string) []*primitive.Primitive {
+	switch subStep {
+	case "a":
+		// Before merge.
+		return prims[:step-1]
+	case "b":
+		// After merge.
+		return prims[:step]
 	default:
-		return "b"
+		// Step 0.
+		return nil
+	}
+}
+
+// pageOf returns the overview page (1-based) presenting the given intermediate
+// step and substep; or 0 if not present.
+func pageOf(pages []stepPage, step int, subStep string) int {
+	for i, sp := range pages {
+		if sp.step == step && sp.subStep == subStep {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// readSVG reads the given SVG image, as output by the dot tool of Graphviz,
+// for inlining in an HTML page.
+func readSVG(svgPath string) (template.HTML, error) {
+	svg, err := ioutil.ReadFile(svgPath)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	// Strip XML declaration and doctype.
+	if pos := bytes.Index(svg, []byte("<svg")); pos != -1 {
+		svg = svg[pos:]
+	}
+	return template.HTML(svg), nil
+}
+
+// outputImg outputs an image representation of the given DOT file by running
+// the dot tool of Graphviz. The image format is determined by the file
+// extension of imgPath (e.g. ".png" or ".svg").
+func outputImg(dotPath, imgPath string) error {
+	format := strings.TrimPrefix(filepath.Ext(imgPath), ".")
+	if err := os.MkdirAll(filepath.Dir(imgPath), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	dbg.Printf("creating file %q", imgPath)
+	cmd := exec.Command("dot", "-T"+format, "-o", imgPath, dotPath)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.WithStack(err)
 	}
+	return nil
 }
@@ -0,0 +1,923 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"github.com/pkg/errors"
+)
+
+// interpValue is a concrete value of the integer and pointer subset of LLVM IR,
+// as evaluated by the interpreter.
+type interpValue struct {
+	// Integer value, truncated to the bit size of its type; or byte offset into
+	// the memory object if pointer.
+	x uint64
+	// Bit size of integer type; or 0 if pointer.
+	bits uint64
+	// Memory object pointed to; or nil if integer or null pointer.
+	obj *memObject
+}
+
+// intValue returns an integer value of the given bit size, truncating x to fit.
+func intValue(x uint64, bits uint64) interpValue {
+	return interpValue{x: truncBits(x, bits), bits: bits}
+}
+
+// truncBits truncates x to the given bit size.
+func truncBits(x uint64, bits uint64) uint64 {
+	if bits >= 64 {
+		return x
+	}
+	return x & (1<<bits - 1)
+}
+
+// signed returns the integer value sign-extended to 64 bits.
+func (v interpValue) signed() int64 {
+	if v.bits == 0 || v.bits >= 64 {
+		return int64(v.x)
+	}
+	shift := 64 - v.bits
+	return int64(v.x<<shift) >> shift
+}
+
+// isPtr reports whether the value is a pointer.
+func (v interpValue) isPtr() bool {
+	return v.bits == 0
+}
+
+// String returns a human-readable representation of the value; integers are
+// presented as signed decimal and pointers as object name and byte offset.
+func (v interpValue) String() string {
+	switch {
+	case !v.isPtr():
+		return strconv.FormatInt(v.signed(), 10)
+	case v.obj == nil && v.x == 0:
+		return "null"
+	case v.obj == nil:
+		return fmt.Sprintf("inttoptr(%d)", v.x)
+	case v.x == 0:
+		return "&" + v.obj.name
+	default:
+		return fmt.Sprintf("&%s+%d", v.obj.name, v.x)
+	}
+}
+
+// memObject is an object of the local memory model of the interpreter, as
+// allocated by an alloca instruction or for a global variable. Memory objects
+// are disjoint; pointer arithmetic never crosses from one object to another.
+//
+// Memory is modelled as cells of stored values rather than as bytes; a load or
+// store must either access a cell exactly (same offset and size), or memory not
+// overlapping any cell. Partially overlapping accesses are not supported.
+type memObject struct {
+	// Object name (e.g. "%x" or "@g").
+	name string
+	// Size in bytes.
+	size uint64
+	// Values stored in the object; indexed by byte offset.
+	cells map[uint64]memCell
+}
+
+// memCell is a value stored in a memory object.
+type memCell struct {
+	// Stored value.
+	v interpValue
+	// Size in bytes of the stored value.
+	size uint64
+}
+
+// access returns the value stored at the given byte offset and of the given
+// size in bytes. The boolean return value indicates whether a value has been
+// stored, as uninitialized memory is presented as zero. An error is returned
+// if the accessed memory partially overlaps a stored value.
+func (obj *memObject) access(offset, size uint64) (interpValue, bool, error) {
+	if c, ok := obj.cells[offset]; ok && c.size == size {
+		return c.v, true, nil
+	}
+	for start, c := range obj.cells {
+		if start < offset+size && offset < start+c.size {
+			addr, stored := interpValue{x: offset, obj: obj}, interpValue{x: start, obj: obj}
+			return interpValue{}, false, errors.Errorf("unsupported overlapping access of size %d at %v (stored value of size %d at %v)", size, addr, c.size, stored)
+		}
+	}
+	return interpValue{}, false, nil
+}
+
+// execTrace is the executed basic block trace of a function, as recorded by
+// the interpreter.
+type execTrace struct {
+	// Function name.
+	Func string
+	// Argument values.
+	Args []string
+	// Names of the executed basic blocks of the function, in execution order.
+	Blocks []string
+	// Return value; or empty if void or execution failed.
+	Result string
+	// Error which terminated execution; or empty if the function returned.
+	Err string
+}
+
+// interp is an interpreter of the integer and pointer subset of LLVM IR.
+type interp struct {
+	// LLVM IR module.
+	m *ir.Module
+	// Memory objects of global variables; indexed by global variable.
+	globals map[*ir.Global]*memObject
+	// Maximum number of executed basic blocks, across all calls.
+	maxSteps int
+	// Number of executed basic blocks, across all calls.
+	steps int
+	// Executed basic block trace of the top-level function.
+	trace *execTrace
+}
+
+// execFunc interprets the given function on concrete argument values, and
+// returns the executed basic block trace. Errors which occur during execution
+// (e.g. unsupported instructions or exceeding the step limit) terminate the
+// trace and are recorded in it; the returned error is non-nil only if the
+// argument values are invalid.
+//
+// - args are the argument values of the function; integer literals (e.g. "42",
+//   "-1" or "0x2A") or "null" for pointer parameters.
+//
+// - maxSteps is the maximum number of executed basic blocks, across all calls.
+func execFunc(m *ir.Module, f *ir.Func, args []string, maxSteps int) (*execTrace, error) {
+	if len(args) != len(f.Params) {
+		return nil, errors.Errorf("invalid number of arguments of function %q; expected %d, got %d", f.Name(), len(f.Params), len(args))
+	}
+	var argValues []interpValue
+	for i, param := range f.Params {
+		v, err := parseArg(args[i], param.Type())
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid argument %d of function %q", i+1, f.Name())
+		}
+		argValues = append(argValues, v)
+	}
+	trace := &execTrace{Func: f.Name(), Args: args}
+	in := &interp{
+		m:        m,
+		globals:  make(map[*ir.Global]*memObject),
+		maxSteps: maxSteps,
+		trace:    trace,
+	}
+	result, err := in.call(f, argValues, 0)
+	if err != nil {
+		trace.Err = err.Error()
+		return trace, nil
+	}
+	if !types.Equal(f.Sig.RetType, types.Void) {
+		trace.Result = result.String()
+	}
+	return trace, nil
+}
+
+// parseArg parses the given argument value of the specified type.
+func parseArg(s string, typ types.Type) (interpValue, error) {
+	switch t := typ.(type) {
+	case *types.IntType:
+		if x, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return intValue(uint64(x), t.BitSize), nil
+		}
+		x, err := strconv.ParseUint(s, 0, 64)
+		if err != nil {
+			return interpValue{}, errors.Errorf("invalid integer literal %q", s)
+		}
+		return intValue(x, t.BitSize), nil
+	case *types.PointerType:
+		if s != "null" {
+			return interpValue{}, errors.Errorf("invalid pointer argument %q; only null is supported", s)
+		}
+		return interpValue{}, nil
+	default:
+		return interpValue{}, errors.Errorf("support for parameter type %v not yet implemented", typ)
+	}
+}
+
+// frame is the call frame of a function invocation.
+type frame struct {
+	// Values of parameters and instructions; indexed by value.
+	locals map[value.Value]interpValue
+}
+
+// call interprets the given function on the given argument values.
+//
+// - depth is the call depth; the basic blocks of the top-level function (depth
+//   0) are recorded in the trace.
+func (in *interp) call(f *ir.Func, args []interpValue, depth int) (interpValue, error) {
+	if len(f.Blocks) == 0 {
+		return in.callExternal(f)
+	}
+	fr := &frame{locals: make(map[value.Value]interpValue)}
+	for i, param := range f.Params {
+		fr.locals[param] = args[i]
+	}
+	var prev *ir.Block
+	block := f.Blocks[0]
+	for {
+		if in.steps >= in.maxSteps {
+			return interpValue{}, errors.Errorf("step limit reached; executed %d basic blocks", in.steps)
+		}
+		in.steps++
+		if depth == 0 {
+			in.trace.Blocks = append(in.trace.Blocks, block.Name())
+		}
+		// Evaluate phi instructions simultaneously, based on the predecessor
+		// basic block.
+		phis := make(map[value.Value]interpValue)
+		for _, inst := range block.Insts {
+			phi, ok := inst.(*ir.InstPhi)
+			if !ok {
+				break
+			}
+			v, err := in.evalPhi(fr, phi, prev)
+			if err != nil {
+				return interpValue{}, errors.WithStack(err)
+			}
+			phis[phi] = v
+		}
+		for phi, v := range phis {
+			fr.locals[phi] = v
+		}
+		for _, inst := range block.Insts {
+			if _, ok := inst.(*ir.InstPhi); ok {
+				continue
+			}
+			if err := in.execInst(fr, inst, depth); err != nil {
+				return interpValue{}, errors.Wrapf(err, "%s: %s", block.Name(), inst.LLString())
+			}
+		}
+		// Execute terminator.
+		var next *ir.Block
+		switch term := block.Term.(type) {
+		case *ir.TermRet:
+			if term.X == nil {
+				return interpValue{}, nil
+			}
+			return in.eval(fr, term.X)
+		case *ir.TermBr:
+			next = term.Target
+		case *ir.TermCondBr:
+			cond, err := in.eval(fr, term.Cond)
+			if err != nil {
+				return interpValue{}, errors.WithStack(err)
+			}
+			if cond.x != 0 {
+				next = term.TargetTrue
+			} else {
+				next = term.TargetFalse
+			}
+		case *ir.TermSwitch:
+			x, err := in.eval(fr, term.X)
+			if err != nil {
+				return interpValue{}, errors.WithStack(err)
+			}
+			next = term.TargetDefault
+			for _, c := range term.Cases {
+				y, err := in.eval(fr, c.X)
+				if err != nil {
+					return interpValue{}, errors.WithStack(err)
+				}
+				if x.x == y.x {
+					next = c.Target
+					break
+				}
+			}
+		case *ir.TermUnreachable:
+			return interpValue{}, errors.Errorf("%s: reached unreachable terminator", block.Name())
+		default:
+			return interpValue{}, errors.Errorf("%s: support for terminator %T not yet implemented", block.Name(), term)
+		}
+		prev, block = block, next
+	}
+}
+
+// callExternal interprets a call to the given function declaration. Debug and
+// lifetime intrinsics are ignored; other external functions are not
+// supported.
+func (in *interp) callExternal(f *ir.Func) (interpValue, error) {
+	name := f.Name()
+	if strings.HasPrefix(name, "llvm.dbg.") || strings.HasPrefix(name, "llvm.lifetime.") {
+		return interpValue{}, nil
+	}
+	return interpValue{}, errors.Errorf("support for calls to external function %q not yet implemented", name)
+}
+
+// evalPhi evaluates the given phi instruction, based on the predecessor basic
+// block.
+func (in *interp) evalPhi(fr *frame, phi *ir.InstPhi, prev *ir.Block) (interpValue, error) {
+	for _, inc := range phi.Incs {
+		if inc.Pred == prev {
+			return in.eval(fr, inc.X)
+		}
+	}
+	return interpValue{}, errors.Errorf("no incoming value of %s for predecessor basic block", phi.Ident())
+}
+
+// execInst executes the given non-terminator instruction.
+func (in *interp) execInst(fr *frame, inst ir.Instruction, depth int) error {
+	switch inst := inst.(type) {
+	// Memory instructions.
+	case *ir.InstAlloca:
+		size, err := sizeOf(inst.ElemType)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if inst.NElems != nil {
+			n, err := in.eval(fr, inst.NElems)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			size *= n.x
+		}
+		obj := &memObject{name: inst.Ident(), size: size, cells: make(map[uint64]memCell)}
+		fr.locals[inst] = interpValue{obj: obj}
+	case *ir.InstLoad:
+		src, err := in.eval(fr, inst.Src)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		v, err := load(src, inst.Type())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fr.locals[inst] = v
+	case *ir.InstStore:
+		v, err := in.eval(fr, inst.Src)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		dst, err := in.eval(fr, inst.Dst)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := store(dst, v, inst.Src.Type()); err != nil {
+			return errors.WithStack(err)
+		}
+	case *ir.InstGetElementPtr:
+		v, err := in.gep(fr, inst.ElemType, inst.Src, inst.Indices)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fr.locals[inst] = v
+	// Binary and bitwise instructions.
+	case *ir.InstAdd, *ir.InstSub, *ir.InstMul, *ir.InstUDiv, *ir.InstSDiv, *ir.InstURem, *ir.InstSRem, *ir.InstShl, *ir.InstLShr, *ir.InstAShr, *ir.InstAnd, *ir.InstOr, *ir.InstXor:
+		v, err := in.binOp(fr, inst)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fr.locals[inst.(value.Value)] = v
+	// Conversion instructions.
+	case *ir.InstTrunc:
+		return in.conv(fr, inst, inst.From, inst.To, false)
+	case *ir.InstZExt:
+		return in.conv(fr, inst, inst.From, inst.To, false)
+	case *ir.InstSExt:
+		return in.conv(fr, inst, inst.From, inst.To, true)
+	case *ir.InstBitCast:
+		return in.conv(fr, inst, inst.From, inst.To, false)
+	case *ir.InstPtrToInt:
+		return in.conv(fr, inst, inst.From, inst.To, false)
+	case *ir.InstIntToPtr:
+		return in.conv(fr, inst, inst.From, inst.To, false)
+	// Other instructions.
+	case *ir.InstICmp:
+		x, err := in.eval(fr, inst.X)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		y, err := in.eval(fr, inst.Y)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		b, err := icmp(inst.Pred, x, y)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		v := intValue(0, 1)
+		if b {
+			v.x = 1
+		}
+		fr.locals[inst] = v
+	case *ir.InstSelect:
+		cond, err := in.eval(fr, inst.Cond)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		operand := inst.Y
+		if cond.x != 0 {
+			operand = inst.X
+		}
+		v, err := in.eval(fr, operand)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fr.locals[inst] = v
+	case *ir.InstCall:
+		callee, ok := inst.Callee.(*ir.Func)
+		if !ok {
+			return errors.Errorf("support for indirect calls not yet implemented")
+		}
+		if len(callee.Blocks) == 0 {
+			// Ignore the arguments of intrinsics, which may be metadata.
+			v, err := in.callExternal(callee)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			fr.locals[inst] = v
+			break
+		}
+		var args []interpValue
+		for _, arg := range inst.Args {
+			v, err := in.eval(fr, arg)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			args = append(args, v)
+		}
+		v, err := in.call(callee, args, depth+1)
+		if err != nil {
+			return errors.Wrapf(err, "in call to %s", callee.Ident())
+		}
+		fr.locals[inst] = v
+	default:
+		return errors.Errorf("support for instruction %T not yet implemented", inst)
+	}
+	return nil
+}
+
+// binOp evaluates the given binary or bitwise instruction.
+func (in *interp) binOp(fr *frame, inst ir.Instruction) (interpValue, error) {
+	var xv, yv value.Value
+	switch inst := inst.(type) {
+	case *ir.InstAdd:
+		xv, yv = inst.X, inst.Y
+	case *ir.InstSub:
+		xv, yv = inst.X, inst.Y
+	case *ir.InstMul:
+		xv, yv = inst.X, inst.Y
+	case *ir.InstUDiv:
+		xv, yv = inst.X, inst.Y
+	case *ir.InstSDiv:
+		xv, yv = inst.X, inst.Y
+	case *ir.InstURem:
+		xv, yv = inst.X, inst.Y
+	case *ir.InstSRem:
+		xv, yv = inst.X, inst.Y
+	case *ir.InstShl:
+		xv, yv = inst.X, inst.Y
+	case *ir.InstLShr:
+		xv, yv = inst.X, inst.Y
+	case *ir.InstAShr:
+		xv, yv = inst.X, inst.Y
+	case *ir.InstAnd:
+		xv, yv = inst.X, inst.Y
+	case *ir.InstOr:
+		xv, yv = inst.X, inst.Y
+	case *ir.InstXor:
+		xv, yv = inst.X, inst.Y
+	}
+	x, err := in.eval(fr, xv)
+	if err != nil {
+		return interpValue{}, errors.WithStack(err)
+	}
+	y, err := in.eval(fr, yv)
+	if err != nil {
+		return interpValue{}, errors.WithStack(err)
+	}
+	if x.isPtr() || y.isPtr() {
+		return interpValue{}, errors.Errorf("support for arithmetic on pointers not yet implemented")
+	}
+	bits := x.bits
+	switch inst.(type) {
+	case *ir.InstAdd:
+		return intValue(x.x+y.x, bits), nil
+	case *ir.InstSub:
+		return intValue(x.x-y.x, bits), nil
+	case *ir.InstMul:
+		return intValue(x.x*y.x, bits), nil
+	case *ir.InstUDiv, *ir.InstSDiv, *ir.InstURem, *ir.InstSRem:
+		if y.x == 0 {
+			return interpValue{}, errors.Errorf("division by zero")
+		}
+		switch inst.(type) {
+		case *ir.InstUDiv:
+			return intValue(x.x/y.x, bits), nil
+		case *ir.InstSDiv:
+			return intValue(uint64(x.signed()/y.signed()), bits), nil
+		case *ir.InstURem:
+			return intValue(x.x%y.x, bits), nil
+		default:
+			return intValue(uint64(x.signed()%y.signed()), bits), nil
+		}
+	case *ir.InstShl:
+		return intValue(x.x<<y.x, bits), nil
+	case *ir.InstLShr:
+		return intValue(x.x>>y.x, bits), nil
+	case *ir.InstAShr:
+		return intValue(uint64(x.signed()>>y.x), bits), nil
+	case *ir.InstAnd:
+		return intValue(x.x&y.x, bits), nil
+	case *ir.InstOr:
+		return intValue(x.x|y.x, bits), nil
+	default:
+		return intValue(x.x^y.x, bits), nil
+	}
+}
+
+// conv evaluates the given conversion instruction.
+//
+// - signExt specifies whether to sign-extend integers.
+func (in *interp) conv(fr *frame, inst value.Value, from value.Value, to types.Type, signExt bool) error {
+	x, err := in.eval(fr, from)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	v, err := convert(x, to, signExt)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	fr.locals[inst] = v
+	return nil
+}
+
+// convert converts the given value to the specified type.
+//
+// - signExt specifies whether to sign-extend integers.
+func convert(x interpValue, to types.Type, signExt bool) (interpValue, error) {
+	switch to := to.(type) {
+	case *types.IntType:
+		if x.isPtr() {
+			if x.obj != nil {
+				return interpValue{}, errors.Errorf("support for converting pointer %v to integer not yet implemented", x)
+			}
+			return intValue(x.x, to.BitSize), nil
+		}
+		if signExt {
+			return intValue(uint64(x.signed()), to.BitSize), nil
+		}
+		return intValue(x.x, to.BitSize), nil
+	case *types.PointerType:
+		if x.isPtr() {
+			return x, nil
+		}
+		return interpValue{x: x.x}, nil
+	default:
+		return interpValue{}, errors.Errorf("support for conversion to type %v not yet implemented", to)
+	}
+}
+
+// icmp evaluates the integer comparison of x and y using the given predicate.
+func icmp(pred enum.IPred, x, y interpValue) (bool, error) {
+	if x.obj != y.obj {
+		// Pointers to distinct memory objects are never equal, and have no
+		// relative order.
+		switch pred {
+		case enum.IPredEQ:
+			return false, nil
+		case enum.IPredNE:
+			return true, nil
+		default:
+			return false, errors.Errorf("support for relational comparison of pointers to distinct objects not yet implemented")
+		}
+	}
+	switch pred {
+	case enum.IPredEQ:
+		return x.x == y.x, nil
+	case enum.IPredNE:
+		return x.x != y.x, nil
+	case enum.IPredSGE:
+		return x.signed() >= y.signed(), nil
+	case enum.IPredSGT:
+		return x.signed() > y.signed(), nil
+	case enum.IPredSLE:
+		return x.signed() <= y.signed(), nil
+	case enum.IPredSLT:
+		return x.signed() < y.signed(), nil
+	case enum.IPredUGE:
+		return x.x >= y.x, nil
+	case enum.IPredUGT:
+		return x.x > y.x, nil
+	case enum.IPredULE:
+		return x.x <= y.x, nil
+	case enum.IPredULT:
+		return x.x < y.x, nil
+	default:
+		return false, errors.Errorf("support for integer predicate %v not yet implemented", pred)
+	}
+}
+
+// gep evaluates the address computed by a getelementptr instruction or
+// constant expression.
+func (in *interp) gep(fr *frame, elemType types.Type, src value.Value, indices []value.Value) (interpValue, error) {
+	ptr, err := in.eval(fr, src)
+	if err != nil {
+		return interpValue{}, errors.WithStack(err)
+	}
+	t := elemType
+	for i, index := range indices {
+		idx, err := in.eval(fr, index)
+		if err != nil {
+			return interpValue{}, errors.WithStack(err)
+		}
+		if i == 0 {
+			// The first index steps over the source pointer.
+			size, err := sizeOf(t)
+			if err != nil {
+				return interpValue{}, errors.WithStack(err)
+			}
+			ptr.x += uint64(idx.signed()) * size
+			continue
+		}
+		switch tt := t.(type) {
+		case *types.ArrayType:
+			size, err := sizeOf(tt.ElemType)
+			if err != nil {
+				return interpValue{}, errors.WithStack(err)
+			}
+			ptr.x += uint64(idx.signed()) * size
+			t = tt.ElemType
+		case *types.StructType:
+			if idx.x >= uint64(len(tt.Fields)) {
+				return interpValue{}, errors.Errorf("invalid getelementptr field index %d of struct type %v with %d fields", idx.x, tt, len(tt.Fields))
+			}
+			offset, err := fieldOffset(tt, idx.x)
+			if err != nil {
+				return interpValue{}, errors.WithStack(err)
+			}
+			ptr.x += offset
+			t = tt.Fields[idx.x]
+		default:
+			return interpValue{}, errors.Errorf("support for getelementptr indexing into type %v not yet implemented", t)
+		}
+	}
+	return ptr, nil
+}
+
+// load loads a value of the given type from memory.
+func load(ptr interpValue, typ types.Type) (interpValue, error) {
+	if ptr.obj == nil {
+		return interpValue{}, errors.Errorf("load from invalid address %v", ptr)
+	}
+	size, err := sizeOf(typ)
+	if err != nil {
+		return interpValue{}, errors.WithStack(err)
+	}
+	if ptr.x > ptr.obj.size || size > ptr.obj.size-ptr.x {
+		return interpValue{}, errors.Errorf("out of bounds load from %v (object size %d)", ptr, ptr.obj.size)
+	}
+	v, ok, err := ptr.obj.access(ptr.x, size)
+	if err != nil {
+		return interpValue{}, errors.WithStack(err)
+	}
+	if !ok {
+		// Uninitialized memory is presented as zero.
+		v = zeroValue(typ)
+	}
+	return convert(v, typ, false)
+}
+
+// store stores the value of the given type to memory.
+func store(ptr interpValue, v interpValue, typ types.Type) error {
+	if ptr.obj == nil {
+		return errors.Errorf("store to invalid address %v", ptr)
+	}
+	size, err := sizeOf(typ)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if ptr.x > ptr.obj.size || size > ptr.obj.size-ptr.x {
+		return errors.Errorf("out of bounds store to %v (object size %d)", ptr, ptr.obj.size)
+	}
+	// Overwrite the value previously stored at the same location, if any.
+	if _, _, err := ptr.obj.access(ptr.x, size); err != nil {
+		return errors.WithStack(err)
+	}
+	ptr.obj.cells[ptr.x] = memCell{v: v, size: size}
+	return nil
+}
+
+// zeroValue returns the zero value of the given type.
+func zeroValue(typ types.Type) interpValue {
+	if t, ok := typ.(*types.IntType); ok {
+		return intValue(0, t.BitSize)
+	}
+	return interpValue{}
+}
+
+// eval evaluates the given value in the call frame; or as a constant if fr is
+// nil.
+func (in *interp) eval(fr *frame, v value.Value) (interpValue, error) {
+	if fr != nil {
+		if x, ok := fr.locals[v]; ok {
+			return x, nil
+		}
+	}
+	switch v := v.(type) {
+	case *constant.Int:
+		t := v.Typ
+		if t.BitSize > 64 {
+			return interpValue{}, errors.Errorf("support for integer type %v not yet implemented", t)
+		}
+		if v.X.Sign() < 0 {
+			return intValue(uint64(v.X.Int64()), t.BitSize), nil
+		}
+		return intValue(v.X.Uint64(), t.BitSize), nil
+	case *constant.Null:
+		return interpValue{}, nil
+	case *constant.Undef:
+		return zeroValue(v.Typ), nil
+	case *constant.ZeroInitializer:
+		return zeroValue(v.Typ), nil
+	case *constant.ExprGetElementPtr:
+		var indices []value.Value
+		for _, index := range v.Indices {
+			if idx, ok := index.(*constant.Index); ok {
+				index = idx.Constant
+			}
+			indices = append(indices, index)
+		}
+		return in.gep(fr, v.ElemType, v.Src, indices)
+	case *constant.ExprBitCast:
+		x, err := in.eval(fr, v.From)
+		if err != nil {
+			return interpValue{}, errors.WithStack(err)
+		}
+		return convert(x, v.To, false)
+	case *constant.ExprPtrToInt:
+		x, err := in.eval(fr, v.From)
+		if err != nil {
+			return interpValue{}, errors.WithStack(err)
+		}
+		return convert(x, v.To, false)
+	case *constant.ExprIntToPtr:
+		x, err := in.eval(fr, v.From)
+		if err != nil {
+			return interpValue{}, errors.WithStack(err)
+		}
+		return convert(x, v.To, false)
+	case *ir.Global:
+		obj, err := in.global(v)
+		if err != nil {
+			return interpValue{}, errors.WithStack(err)
+		}
+		return interpValue{obj: obj}, nil
+	default:
+		return interpValue{}, errors.Errorf("support for value %v of type %T not yet implemented", v.Ident(), v)
+	}
+}
+
+// global returns the memory object of the given global variable, allocating
+// and initializing it on first use.
+func (in *interp) global(g *ir.Global) (*memObject, error) {
+	if obj, ok := in.globals[g]; ok {
+		return obj, nil
+	}
+	size, err := sizeOf(g.ContentType)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	obj := &memObject{name: g.Ident(), size: size, cells: make(map[uint64]memCell)}
+	in.globals[g] = obj
+	if g.Init != nil {
+		if err := in.initMem(obj, 0, g.Init); err != nil {
+			return nil, errors.Wrapf(err, "unable to initialize global variable %s", g.Ident())
+		}
+	}
+	return obj, nil
+}
+
+// initMem stores the given constant initializer in memory at the specified
+// byte offset.
+func (in *interp) initMem(obj *memObject, offset uint64, init constant.Constant) error {
+	switch init := init.(type) {
+	case *constant.ZeroInitializer:
+		// Uninitialized memory is presented as zero.
+		return nil
+	case *constant.CharArray:
+		for i, b := range init.X {
+			obj.cells[offset+uint64(i)] = memCell{v: intValue(uint64(b), 8), size: 1}
+		}
+		return nil
+	case *constant.Array:
+		size, err := sizeOf(init.Typ.ElemType)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for i, elem := range init.Elems {
+			if err := in.initMem(obj, offset+uint64(i)*size, elem); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	default:
+		v, err := in.eval(nil, init)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		size, err := sizeOf(init.Type())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		obj.cells[offset] = memCell{v: v, size: size}
+		return nil
+	}
+}
+
+// sizeOf returns the size in bytes of the given type, using natural alignment
+// on a 64-bit target.
+func sizeOf(typ types.Type) (uint64, error) {
+	switch t := typ.(type) {
+	case *types.IntType:
+		size := uint64(1)
+		for size*8 < t.BitSize {
+			size *= 2
+		}
+		return size, nil
+	case *types.PointerType:
+		return 8, nil
+	case *types.ArrayType:
+		size, err := sizeOf(t.ElemType)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		return t.Len * size, nil
+	case *types.StructType:
+		size, err := fieldOffset(t, uint64(len(t.Fields)))
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		align, err := alignOf(t)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		return alignUp(size, align), nil
+	default:
+		return 0, errors.Errorf("support for type %v not yet implemented", typ)
+	}
+}
+
+// alignOf returns the alignment in bytes of the given type.
+func alignOf(typ types.Type) (uint64, error) {
+	switch t := typ.(type) {
+	case *types.ArrayType:
+		return alignOf(t.ElemType)
+	case *types.StructType:
+		align := uint64(1)
+		if t.Packed {
+			return align, nil
+		}
+		for _, field := range t.Fields {
+			a, err := alignOf(field)
+			if err != nil {
+				return 0, errors.WithStack(err)
+			}
+			if a > align {
+				align = a
+			}
+		}
+		return align, nil
+	default:
+		return sizeOf(typ)
+	}
+}
+
+// fieldOffset returns the byte offset of the given field of the structure
+// type; or the size of the fields (without trailing padding) if index is the
+// number of fields.
+func fieldOffset(t *types.StructType, index uint64) (uint64, error) {
+	if index > uint64(len(t.Fields)) {
+		return 0, errors.Errorf("invalid field index %d of structure type %v", index, t)
+	}
+	offset := uint64(0)
+	for i, field := range t.Fields {
+		if !t.Packed {
+			align, err := alignOf(field)
+			if err != nil {
+				return 0, errors.WithStack(err)
+			}
+			offset = alignUp(offset, align)
+		}
+		if uint64(i) == index {
+			break
+		}
+		size, err := sizeOf(field)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		offset += size
+	}
+	return offset, nil
+}
+
+// alignUp rounds x up to a multiple of align.
+func alignUp(x, align uint64) uint64 {
+	return (x + align - 1) / align * align
+}
@@ -0,0 +1,182 @@
+package main
+
+// derivedGraph is a graph of the derived sequence of a control flow graph, in
+// which each node represents a set of basic blocks of the original control flow
+// graph; a single basic block in the first graph of the sequence, and an
+// interval of the preceding graph in the graphs derived from it.
+type derivedGraph struct {
+	// Node names; the name of the header basic block of each node. Indexed by
+	// node.
+	names []string
+	// Successors of each node; indexed by node.
+	succs [][]int
+	// Predecessors of each node; indexed by node.
+	preds [][]int
+	// Basic blocks of the original control flow graph represented by each
+	// node, header basic block first; indexed by node.
+	blocks [][]string
+	// Intervals of the graph, in order of discovery. Each interval lists its
+	// nodes in order of addition, header node first.
+	intervals [][]int
+}
+
+// derivedSequence returns the derived sequence of graphs G1, ..., Gn of the
+// given control flow graph, as defined by Allen and Cocke. G1 is the control
+// flow graph restricted to the nodes reachable from the entry node, and each
+// following graph has a node for every interval of the preceding graph. The
+// sequence ends with the limit graph Gn, which is not reduced any further by
+// interval analysis; the control flow graph is reducible if and only if the
+// limit graph is a single node.
+func derivedSequence(g *cfg) []*derivedGraph {
+	if len(g.names) == 0 {
+		return nil
+	}
+	d := firstDerived(g)
+	seq := []*derivedGraph{d}
+	for {
+		d.intervals = findIntervals(d)
+		if len(d.intervals) == len(d.names) {
+			// Limit graph; each node is its own interval.
+			break
+		}
+		d = d.derive()
+		seq = append(seq, d)
+	}
+	return seq
+}
+
+// firstDerived returns the first graph G1 of the derived sequence of the given
+// control flow graph; i.e. the control flow graph restricted to the nodes
+// reachable from the entry node.
+func firstDerived(g *cfg) *derivedGraph {
+	t := depthFirst(g)
+	// Map from control flow graph node to G1 node; or -1 if unreachable.
+	index := make([]int, len(g.names))
+	d := &derivedGraph{}
+	for n, name := range g.names {
+		index[n] = -1
+		if t.pre[n] == 0 {
+			continue
+		}
+		index[n] = len(d.names)
+		d.names = append(d.names, name)
+		d.blocks = append(d.blocks, []string{name})
+	}
+	d.succs = make([][]int, len(d.names))
+	d.preds = make([][]int, len(d.names))
+	for from, succs := range g.succs {
+		if index[from] == -1 {
+			continue
+		}
+		for _, to := range succs {
+			d.addEdge(index[from], index[to])
+		}
+	}
+	return d
+}
+
+// addEdge adds an edge from -> to to the graph, unless already present.
+func (d *derivedGraph) addEdge(from, to int) {
+	for _, succ := range d.succs[from] {
+		if succ == to {
+			return
+		}
+	}
+	d.succs[from] = append(d.succs[from], to)
+	d.preds[to] = append(d.preds[to], from)
+}
+
+// findIntervals partitions the nodes of the given graph into intervals, using
+// the algorithm of Allen and Cocke. The interval I(h) of the header node h is
+// the maximal single-entry subgraph in which h is the only entry node and
+// every cycle contains h; it is grown from h by repeatedly adding nodes whose
+// predecessors are all in I(h). The entry node is the first header, and every
+// node outside of an interval with a predecessor inside of it is the header of
+// a later interval.
+func findIntervals(d *derivedGraph) [][]int {
+	const entry = 0
+	// Interval of each node; or -1 if not yet in an interval.
+	intervalOf := make([]int, len(d.names))
+	for i := range intervalOf {
+		intervalOf[i] = -1
+	}
+	isHeader := make([]bool, len(d.names))
+	isHeader[entry] = true
+	headers := []int{entry}
+	var intervals [][]int
+	for len(headers) > 0 {
+		h := headers[0]
+		headers = headers[1:]
+		id := len(intervals)
+		interval := []int{h}
+		intervalOf[h] = id
+		// Visit the nodes of the interval in order of addition; the successors of
+		// a node are reconsidered each time one of their predecessors is added.
+		for i := 0; i < len(interval); i++ {
+			for _, succ := range d.succs[interval[i]] {
+				if intervalOf[succ] != -1 || isHeader[succ] {
+					continue
+				}
+				if d.allPredsIn(succ, intervalOf, id) {
+					intervalOf[succ] = id
+					interval = append(interval, succ)
+				}
+			}
+		}
+		// Nodes outside of the interval reached from within are headers.
+		for _, n := range interval {
+			for _, succ := range d.succs[n] {
+				if intervalOf[succ] == -1 && !isHeader[succ] {
+					isHeader[succ] = true
+					headers = append(headers, succ)
+				}
+			}
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals
+}
+
+// allPredsIn reports whether all predecessors of the given node are in the
+// specified interval.
+func (d *derivedGraph) allPredsIn(n int, intervalOf []int, id int) bool {
+	for _, pred := range d.preds[n] {
+		if intervalOf[pred] != id {
+			return false
+		}
+	}
+	return true
+}
+
+// derive returns the graph derived from the intervals of the given graph, in
+// which each interval is collapsed into a single node, named after the header
+// node of the interval. An edge connects two nodes of the derived graph if an
+// edge of the given graph leaves the first interval for the header of the
+// second; edges within an interval are dropped.
+func (d *derivedGraph) derive() *derivedGraph {
+	n := len(d.intervals)
+	next := &derivedGraph{
+		names:  make([]string, n),
+		succs:  make([][]int, n),
+		preds:  make([][]int, n),
+		blocks: make([][]string, n),
+	}
+	intervalOf := make([]int, len(d.names))
+	for id, interval := range d.intervals {
+		next.names[id] = d.names[interval[0]]
+		for _, node := range interval {
+			intervalOf[node] = id
+			next.blocks[id] = append(next.blocks[id], d.blocks[node]...)
+		}
+	}
+	for id, interval := range d.intervals {
+		for _, node := range interval {
+			for _, succ := range d.succs[node] {
+				if to := intervalOf[succ]; to != id {
+					next.addEdge(id, to)
+				}
+			}
+		}
+	}
+	return next
+}
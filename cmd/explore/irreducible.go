@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mewmew/lnp/pkg/cfa/primitive"
+)
+
+// residual is the residual control flow graph of a function, which remains
+// after merging the nodes of every recovered control flow primitive.
+type residual struct {
+	// Node names of the residual graph, ordered by their first basic block.
+	nodes []string
+	// Original basic block names contained within each node; indexed by node
+	// name.
+	blocks map[string][]string
+	// Successors of each node; indexed by node name.
+	succs map[string][]string
+	// Irreducible regions of the residual graph.
+	regions []*region
+}
+
+// region is an irreducible region of the residual control flow graph; a
+// strongly connected component with multiple entry nodes.
+type region struct {
+	// Node names of the strongly connected component.
+	nodes []string
+	// Entry nodes of the strongly connected component; i.e. nodes with
+	// predecessors outside of the component, or the entry node of the function.
+	entries []string
+}
+
+// newResidual returns the residual control flow graph of the given function,
+// after merging the nodes of the recovered control flow primitives.
+func newResidual(g *cfg, prims []*primitive.Primitive) *residual {
+	r := &residual{
+		blocks: mergedBlocks(g.f, prims),
+		succs:  make(map[string][]string),
+	}
+	// Map from original basic block to residual graph node.
+	nodeOf := make(map[string]string)
+	for node, blocks := range r.blocks {
+		for _, block := range blocks {
+			nodeOf[block] = node
+		}
+	}
+	for _, name := range g.names {
+		if _, ok := r.blocks[name]; ok {
+			r.nodes = append(r.nodes, name)
+		}
+	}
+	// Compute edges between residual graph nodes.
+	isEdge := make(map[[2]string]bool)
+	for from, succs := range g.succs {
+		for _, to := range succs {
+			edge := [2]string{nodeOf[g.names[from]], nodeOf[g.names[to]]}
+			if edge[0] == edge[1] || isEdge[edge] {
+				continue
+			}
+			isEdge[edge] = true
+			r.succs[edge[0]] = append(r.succs[edge[0]], edge[1])
+		}
+	}
+	// Locate irreducible regions.
+	entry := nodeOf[g.names[0]]
+	for _, scc := range r.sccs() {
+		if len(scc) < 2 {
+			continue
+		}
+		inSCC := make(map[string]bool)
+		for _, node := range scc {
+			inSCC[node] = true
+		}
+		isEntry := make(map[string]bool)
+		for _, from := range r.nodes {
+			if inSCC[from] {
+				continue
+			}
+			for _, to := range r.succs[from] {
+				if inSCC[to] {
+					isEntry[to] = true
+				}
+			}
+		}
+		if inSCC[entry] {
+			isEntry[entry] = true
+		}
+		var entries []string
+		for _, node := range scc {
+			if isEntry[node] {
+				entries = append(entries, node)
+			}
+		}
+		if len(entries) > 1 {
+			r.regions = append(r.regions, &region{nodes: scc, entries: entries})
+		}
+	}
+	return r
+}
+
+// sccs returns the strongly connected components of the residual graph, using
+// Tarjan's algorithm. The nodes of each component are ordered as in r.nodes.
+func (r *residual) sccs() [][]string {
+	var (
+		index   = make(map[string]int)
+		lowlink = make(map[string]int)
+		onStack = make(map[string]bool)
+		stack   []string
+		sccs    [][]string
+	)
+	var connect func(v string)
+	connect = func(v string) {
+		index[v] = len(index)
+		lowlink[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range r.succs[v] {
+			if _, ok := index[w]; !ok {
+				connect(w)
+				if lowlink[w] < lowlink[v] {
+					lowlink[v] = lowlink[w]
+				}
+			} else if onStack[w] && index[w] < lowlink[v] {
+				lowlink[v] = index[w]
+			}
+		}
+		if lowlink[v] != index[v] {
+			return
+		}
+		inSCC := make(map[string]bool)
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			inSCC[w] = true
+			if w == v {
+				break
+			}
+		}
+		var scc []string
+		for _, node := range r.nodes {
+			if inSCC[node] {
+				scc = append(scc, node)
+			}
+		}
+		sccs = append(sccs, scc)
+	}
+	for _, v := range r.nodes {
+		if _, ok := index[v]; !ok {
+			connect(v)
+		}
+	}
+	return sccs
+}
+
+// isComplete reports whether control flow recovery reduced the control flow
+// graph to a single node.
+func (r *residual) isComplete() bool {
+	return len(r.nodes) <= 1
+}
+
+// regionBlocks returns the original basic block names contained within the
+// irreducible regions of the residual graph.
+func (r *residual) regionBlocks() []string {
+	var blocks []string
+	for _, reg := range r.regions {
+		for _, node := range reg.nodes {
+			blocks = append(blocks, r.blocks[node]...)
+		}
+	}
+	return blocks
+}
+
+// desc returns a description of why control flow recovery stopped before
+// reducing the control flow graph to a single node.
+func (r *residual) desc() string {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "Control flow recovery stopped with %d nodes remaining in the control flow graph (%s). ", len(r.nodes), strings.Join(r.nodes, ", "))
+	switch len(r.regions) {
+	case 0:
+		buf.WriteString("The remaining graph contains no irreducible regions, but none of its subgraphs match a supported control flow primitive.")
+	case 1:
+		buf.WriteString("The remaining graph contains an irreducible region; a cycle which may be entered through more than one node, and which therefore cannot be structured using single-entry control flow primitives.")
+	default:
+		fmt.Fprintf(buf, "The remaining graph contains %d irreducible regions; cycles which may be entered through more than one node, and which therefore cannot be structured using single-entry control flow primitives.", len(r.regions))
+	}
+	return buf.String()
+}
+
+// regionDescs returns a description of each irreducible region of the residual
+// graph.
+func (r *residual) regionDescs() []string {
+	var descs []string
+	for _, reg := range r.regions {
+		var blocks []string
+		for _, node := range reg.nodes {
+			blocks = append(blocks, r.blocks[node]...)
+		}
+		desc := fmt.Sprintf("Strongly connected component of nodes %s (basic blocks %s) with entry nodes %s.", strings.Join(reg.nodes, ", "), strings.Join(blocks, ", "), strings.Join(reg.entries, ", "))
+		descs = append(descs, desc)
+	}
+	return descs
+}
+
+// dot returns a representation of the residual graph in Graphviz DOT format,
+// with each irreducible region placed in a red cluster and entry nodes of
+// irreducible regions drawn with a double border.
+func (r *residual) dot() string {
+	buf := &strings.Builder{}
+	buf.WriteString("digraph residual {\n")
+	inRegion := make(map[string]bool)
+	for i, reg := range r.regions {
+		fmt.Fprintf(buf, "\tsubgraph cluster_irreducible_%d {\n", i)
+		buf.WriteString("\t\tlabel=\"irreducible region\"\n")
+		buf.WriteString("\t\tcolor=red\n")
+		buf.WriteString("\t\tfontcolor=red\n")
+		isEntry := make(map[string]bool)
+		for _, entry := range reg.entries {
+			isEntry[entry] = true
+		}
+		for _, node := range reg.nodes {
+			inRegion[node] = true
+			fmt.Fprintf(buf, "\t\t%s [fillcolor=red style=filled", dotQuote(node))
+			if isEntry[node] {
+				buf.WriteString(" peripheries=2")
+			}
+			buf.WriteString("]\n")
+		}
+		buf.WriteString("\t}\n")
+	}
+	for _, node := range r.nodes {
+		if !inRegion[node] {
+			fmt.Fprintf(buf, "\t%s\n", dotQuote(node))
+		}
+	}
+	for _, from := range r.nodes {
+		for _, to := range r.succs[from] {
+			fmt.Fprintf(buf, "\t%s -> %s\n", dotQuote(from), dotQuote(to))
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
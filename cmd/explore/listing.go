@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// funcListing is the LLVM IR assembly of a function, as printed by
+// printFunc, with the exact line ranges of each basic block and instruction
+// recorded while printing.
+type funcListing struct {
+	// LLVM IR assembly of the function; identical to f.LLString().
+	source string
+	// Line range (1-based: [start, end]) of each basic block, from label to
+	// terminator; indexed by basic block.
+	blocks map[*ir.Block][2]int
+	// Line range (1-based: [start, end]) of each instruction and terminator;
+	// indexed by instruction or terminator.
+	insts map[ir.LLStringer][2]int
+}
+
+// printFunc prints the LLVM IR assembly of the given function definition,
+// recording the line ranges of each basic block and instruction.
+func printFunc(f *ir.Func) (*funcListing, error) {
+	// Force generate local IDs.
+	if err := f.AssignIDs(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Print function body, as printed by f.LLString(). Note, instructions and
+	// terminators may span multiple lines (e.g. switch terminators).
+	l := &funcListing{
+		blocks: make(map[*ir.Block][2]int),
+		insts:  make(map[ir.LLStringer][2]int),
+	}
+	body := &lineWriter{}
+	body.WriteString("{\n")
+	for i, block := range f.Blocks {
+		if i != 0 {
+			body.WriteString("\n")
+		}
+		label := strings.SplitN(block.LLString(), "\n", 2)[0]
+		start := body.write(label)[0]
+		body.WriteString("\n")
+		for _, inst := range block.Insts {
+			l.insts[inst] = body.write("\t" + inst.LLString())
+			body.WriteString("\n")
+		}
+		termLines := body.write("\t" + block.Term.LLString())
+		l.insts[block.Term] = termLines
+		l.blocks[block] = [2]int{start, termLines[1]}
+		body.WriteString("\n")
+	}
+	if len(f.UseListOrders) > 0 {
+		body.WriteString("\n")
+	}
+	for _, u := range f.UseListOrders {
+		body.WriteString(fmt.Sprintf("\t%s\n", u))
+	}
+	body.WriteString("}")
+	// The function header precedes the function body on the first line, so
+	// line numbers of the body are valid as is.
+	source := f.LLString()
+	if !strings.HasSuffix(source, " "+body.String()) {
+		return nil, errors.Errorf("unable to print body of function %q; mismatch between printed body and LLVM IR assembly of function", f.Ident())
+	}
+	header := strings.TrimSuffix(source, " "+body.String())
+	if n := strings.Count(header, "\n"); n > 0 {
+		l.shift(n)
+	}
+	l.source = source
+	return l, nil
+}
+
+// shift shifts the recorded line ranges by the specified number of lines.
+func (l *funcListing) shift(n int) {
+	for block, lines := range l.blocks {
+		l.blocks[block] = [2]int{lines[0] + n, lines[1] + n}
+	}
+	for inst, lines := range l.insts {
+		l.insts[inst] = [2]int{lines[0] + n, lines[1] + n}
+	}
+}
+
+// blockLines returns the line range (1-based: [start, end]) of the named basic
+// block.
+func (l *funcListing) blockLines(f *ir.Func, blockName string) ([2]int, error) {
+	block, err := findBlock(f, blockName)
+	if err != nil {
+		return [2]int{}, errors.WithStack(err)
+	}
+	return l.blocks[block], nil
+}
+
+// anchors returns the line number of each basic block and instruction of the
+// given function, for linking to specific lines of the LLVM IR assembly;
+// indexed by anchor name, where "bb:NAME" denotes the label of basic block
+// NAME, and "inst:NAME:I" denotes the I-th instruction (0-based) of basic block
+// NAME, with the terminator following the last instruction.
+func (l *funcListing) anchors(f *ir.Func) map[string]int {
+	anchors := make(map[string]int)
+	for _, block := range f.Blocks {
+		blockName := block.Name()
+		anchors["bb:"+blockName] = l.blocks[block][0]
+		for i, inst := range block.Insts {
+			anchors[fmt.Sprintf("inst:%s:%d", blockName, i)] = l.insts[inst][0]
+		}
+		anchors[fmt.Sprintf("inst:%s:%d", blockName, len(block.Insts))] = l.insts[block.Term][0]
+	}
+	return anchors
+}
+
+// lineWriter is a string builder which keeps track of the current line number.
+type lineWriter struct {
+	strings.Builder
+	// Current line number (0-based).
+	line int
+}
+
+// write writes the given string, and returns its line range (1-based: [start,
+// end]).
+func (w *lineWriter) write(s string) [2]int {
+	start := w.line + 1
+	w.WriteString(s)
+	return [2]int{start, start + strings.Count(s, "\n")}
+}
+
+// WriteString writes the given string, keeping track of the current line
+// number.
+func (w *lineWriter) WriteString(s string) (int, error) {
+	w.line += strings.Count(s, "\n")
+	return w.Builder.WriteString(s)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+)
+
+// moduleLLVM is the LLVM IR assembly of a whole module, as presented in the
+// full-module LLVM pane.
+type moduleLLVM struct {
+	// LLVM IR assembly of the module.
+	source string
+	// Line number (1-based) of the definition of each top-level entity
+	// (functions, globals, type definitions, attribute groups and metadata);
+	// indexed by identifier (e.g. "@foo", "%struct.foo", "#0", "!5").
+	defs map[string]int
+}
+
+// reTopLevelDefs is the list of regular expressions matching lines which define
+// top-level entities, where the first submatch is the identifier of the
+// defined entity.
+var reTopLevelDefs = []*regexp.Regexp{
+	// Global variables, aliases and IFuncs.
+	regexp.MustCompile(`^(@(?:"[^"]*"|[-\w$.]+)) = `),
+	// Function declarations and definitions.
+	regexp.MustCompile(`^(?:define|declare)\b[^@]*(@(?:"[^"]*"|[-\w$.]+))\(`),
+	// Type definitions.
+	regexp.MustCompile(`^(%(?:"[^"]*"|[-\w$.]+)) = type\b`),
+	// Attribute group definitions.
+	regexp.MustCompile(`^attributes (#\d+) = `),
+	// Named and unnamed metadata definitions.
+	regexp.MustCompile(`^(!(?:"[^"]*"|[-\w$.]+)) = `),
+}
+
+// newModuleLLVM returns the LLVM IR assembly of the given module, and locates
+// the definitions of its top-level entities.
+func newModuleLLVM(m *ir.Module) *moduleLLVM {
+	mod := &moduleLLVM{
+		source: m.String(),
+		defs:   make(map[string]int),
+	}
+	for i, line := range strings.Split(mod.source, "\n") {
+		for _, re := range reTopLevelDefs {
+			if subs := re.FindStringSubmatch(line); subs != nil {
+				mod.defs[subs[1]] = i + 1
+				break
+			}
+		}
+	}
+	return mod
+}
+
+// moduleLLVM returns the LLVM IR assembly of the whole module, which is
+// generated on first use.
+func (e *explorer) moduleLLVM() *moduleLLVM {
+	if e.modLLVM == nil {
+		e.modLLVM = newModuleLLVM(e.m)
+	}
+	return e.modLLVM
+}
+
+// shiftLines returns the given line ranges, shifted by the specified number of
+// lines.
+func shiftLines(lines [][2]int, shift int) [][2]int {
+	var shifted [][2]int
+	for _, line := range lines {
+		shifted = append(shifted, [2]int{line[0] + shift, line[1] + shift})
+	}
+	return shifted
+}
+
+// shiftDefUse shifts the line numbers of the given def-use chains by the
+// specified number of lines.
+func shiftDefUse(chains map[string]*defUse, shift int) {
+	for _, chain := range chains {
+		chain.Def.Line += shift
+		for i := range chain.Uses {
+			chain.Uses[i].Line += shift
+		}
+	}
+}
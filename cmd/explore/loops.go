@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// loop is a natural loop of a control flow graph. Natural loops sharing the
+// same header node are merged into a single loop.
+type loop struct {
+	// Loop header node.
+	header int
+	// Source nodes of the back edges to the loop header.
+	latches []int
+	// Nodes of the loop body (including the loop header), in basic block
+	// order.
+	body []int
+	// Target nodes of edges leaving the loop, in basic block order.
+	exits []int
+	// Parent loop in the loop nesting forest; or nil if outermost loop.
+	parent *loop
+	// Child loops in the loop nesting forest.
+	children []*loop
+}
+
+// naturalLoops returns the natural loops of the given control flow graph,
+// ordered by loop header. The parent and children of each loop are set to
+// reflect the loop nesting forest.
+//
+// An edge n -> h is a back edge if h dominates n, and the natural loop of the
+// back edge consists of h and all nodes that can reach n without passing
+// through h.
+func naturalLoops(g *cfg, dom *domTree) []*loop {
+	// Locate back edges and compute loop bodies.
+	var loops []*loop
+	for h := range g.names {
+		var latches []int
+		for _, pred := range g.preds[h] {
+			if dom.dominates(h, pred) {
+				latches = append(latches, pred)
+			}
+		}
+		if len(latches) == 0 {
+			continue
+		}
+		inBody := map[int]bool{h: true}
+		stack := append([]int(nil), latches...)
+		for len(stack) > 0 {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if inBody[n] || (n != dom.root && dom.idom[n] == -1) {
+				// Skip visited nodes and nodes unreachable from the entry node.
+				continue
+			}
+			inBody[n] = true
+			stack = append(stack, g.preds[n]...)
+		}
+		l := &loop{header: h, latches: latches}
+		inExits := make(map[int]bool)
+		for n := range g.names {
+			if !inBody[n] {
+				continue
+			}
+			l.body = append(l.body, n)
+			for _, succ := range g.succs[n] {
+				if !inBody[succ] {
+					inExits[succ] = true
+				}
+			}
+		}
+		for n := range g.names {
+			if inExits[n] {
+				l.exits = append(l.exits, n)
+			}
+		}
+		loops = append(loops, l)
+	}
+	// Compute loop nesting forest; the parent of a loop is the smallest other
+	// loop containing its header.
+	for _, l := range loops {
+		for _, candidate := range loops {
+			if candidate == l || len(candidate.body) <= len(l.body) || !candidate.contains(l.header) {
+				continue
+			}
+			if l.parent == nil || len(candidate.body) < len(l.parent.body) {
+				l.parent = candidate
+			}
+		}
+		if l.parent != nil {
+			l.parent.children = append(l.parent.children, l)
+		}
+	}
+	for _, l := range loops {
+		sort.Slice(l.children, func(i, j int) bool {
+			return l.children[i].header < l.children[j].header
+		})
+	}
+	return loops
+}
+
+// contains reports whether the given node is part of the loop body.
+func (l *loop) contains(n int) bool {
+	for _, m := range l.body {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
+
+// depth returns the nesting depth of the loop, where outermost loops have
+// depth 1.
+func (l *loop) depth() int {
+	depth := 1
+	for p := l.parent; p != nil; p = p.parent {
+		depth++
+	}
+	return depth
+}
+
+// summary returns a textual summary of the loop.
+func (l *loop) summary(g *cfg) string {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "Loop with header %s (nesting depth %d) has a body of %d basic blocks (%s)", g.names[l.header], l.depth(), len(l.body), nodeNames(g, l.body))
+	fmt.Fprintf(buf, ", back edges from %s", nodeNames(g, l.latches))
+	if len(l.exits) > 0 {
+		fmt.Fprintf(buf, " and exits to %s", nodeNames(g, l.exits))
+	} else {
+		buf.WriteString(" and no exits")
+	}
+	buf.WriteString(".")
+	if l.parent != nil {
+		fmt.Fprintf(buf, " It is nested within the loop with header %s.", g.names[l.parent.header])
+	}
+	if len(l.children) > 0 {
+		var headers []int
+		for _, child := range l.children {
+			headers = append(headers, child.header)
+		}
+		fmt.Fprintf(buf, " It contains the nested loops with headers %s.", nodeNames(g, headers))
+	}
+	return buf.String()
+}
+
+// nodeNames returns a comma-separated list of the names of the given nodes.
+func nodeNames(g *cfg, nodes []int) string {
+	var names []string
+	for _, n := range nodes {
+		names = append(names, g.names[n])
+	}
+	return strings.Join(names, ", ")
+}
+
+// loopSummaries returns a textual summary of each loop with a header among the
+// given basic blocks.
+func loopSummaries(g *cfg, loops []*loop, blocks []string) []string {
+	var summaries []string
+	for _, n := range g.nodes(blocks) {
+		for _, l := range loops {
+			if l.header == n {
+				summaries = append(summaries, l.summary(g))
+			}
+		}
+	}
+	return summaries
+}
@@ -6,21 +6,73 @@
 // function.
 //
 // For a source file "foo.ll" containing the functions "bar" and "baz" the
-// following HTML files are generated.
+// following HTML files are generated, where index.html presents the call graph
+// of the module and links to the visualization of each function.
 //
-//    * foo_explore/bar.html
-//    * foo_explore/baz.html
+//    * foo_explore/index.html
+//    * foo_explore/bar_0001.html
+//    * foo_explore/baz_0001.html
+//
+// C++ and Rust function names are demangled for display, and output file names
+// are derived from a safe and collision-free slug of the function name.
 //
 // Usage:
 //
 //     explore [OPTION]... [FILE.ll]...
+//     explore diff [OPTION]... OLD_DIR NEW_DIR
+//     explore opt [OPTION]... FILE.c
+//     explore exec [OPTION]... FILE.ll FUNC [ARG]...
+//
+// The diff command compares two explorations of the same LLVM IR module (e.g.
+// before and after a change to restructure2 or ll2go2), aligning the steps of
+// each function and highlighting where the recovered control flow primitives
+// diverge.
+//
+// The opt command compiles a C source file using a list of optimization
+// pipelines (-pipelines flag; default "O0,mem2reg,O2"), explores the LLVM IR
+// of each, and compares the control flow graph size, number of recovered
+// control flow primitives and reconstructed Go source code of each function
+// across optimization pipelines.
+//
+// The exec command interprets the LLVM IR of a function on concrete argument
+// values (integer and pointer subset, with a local memory model), and adds a
+// page replaying the executed basic block trace to the exploration of the
+// module, exploring the module first if not present. The replay highlights the
+// current basic block in the control flow graph, LLVM IR assembly, original C
+// source code and reconstructed Go source code of step 0, and is linked from
+// the overview and control tree pages of the function. Basic blocks are
+// located in the Go source code on a best-effort basis, based on its labels.
+//
+// Execution profiles are presented when terminators carry !prof branch_weights
+// metadata, or when block counts of a profiling run are given (-profile flag).
+// The Profile view of the CFA pane renders block heat colors and edge
+// thickness, and the LLVM pane gains a heat gutter. Without block counts,
+// frequencies are estimated from branch weights, relative to one call of the
+// function.
+//
+// Function filters (-funcs, -exclude and -min-blocks) are applied consistently
+// to every stage of the pipeline.
 //
 // Flags:
 //
+//   -exclude string
+//         comma-separated list of function patterns to exclude
 //   -f    force overwrite existing explore directories
 //   -funcs string
-//         comma-separated list of functions to parse
+//         comma-separated list of function patterns to parse; glob patterns
+//         (e.g. "foo*") or regular expressions prefixed with "re:" (e.g.
+//         "re:^_ZN3foo"), matching either mangled or demangled names
+//   -min-blocks int
+//         minimum number of basic blocks of functions to parse
+//   -module
+//         present LLVM IR assembly of the whole module in the LLVM pane
+//   -profile string
+//         block count file of a profiling run, with one 'FUNC BLOCK COUNT' per
+//         line
 //   -q    suppress non-error messages
+//   -skip-before int
+//         skip substeps before merge of functions with at least this many
+//         recovered primitives (0 never skips)
 //   -style string
 //         style used for syntax highlighting (borland, monokai, vs, ...)
 //         (default "vs")
@@ -33,9 +85,6 @@ import (
 	"log"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"sort"
-	"strings"
 
 	"github.com/llir/llvm/ir"
 	"github.com/mewkiz/pkg/osutil"
@@ -61,6 +110,9 @@ Visualize the stages of the decompiler pipeline.
 Usage:
 
 	explore [OPTION]... [FILE.ll]
+	explore diff [OPTION]... OLD_DIR NEW_DIR
+	explore opt [OPTION]... FILE.c
+	explore exec [OPTION]... FILE.ll FUNC [ARG]...
 
 Flags:
 `
@@ -69,21 +121,56 @@ Flags:
 }
 
 func main() {
+	// Handle `explore diff`, `explore opt` and `explore exec` commands.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			compareMain(os.Args[2:])
+			return
+		case "opt":
+			optMain(os.Args[2:])
+			return
+		case "exec":
+			execMain(os.Args[2:])
+			return
+		}
+	}
 	// Parse command line arguments.
 	var (
+		// exclude represents a comma-separated list of function patterns to
+		// exclude.
+		exclude string
 		// force specifies whether to force overwrite existing explore
 		// directories.
 		force bool
-		// funcs represents a comma-separated list of functions to parse.
+		// funcs represents a comma-separated list of function patterns to parse.
 		funcs string
+		// minBlocks specifies the minimum number of basic blocks of functions to
+		// parse.
+		minBlocks int
+		// fullModule specifies whether to present the LLVM IR assembly of the
+		// whole module in the LLVM pane.
+		fullModule bool
+		// profilePath specifies the path of the block count file of a profiling
+		// run.
+		profilePath string
 		// quiet specifies whether to suppress non-error messages.
 		quiet bool
+		// skipBefore specifies the minimum number of recovered control flow
+		// primitives of functions for which to skip the substeps before merge;
+		// or 0 to never skip.
+		skipBefore int
 		// style specifies the style used for syntax highlighting.
 		style string
 	)
+	flag.StringVar(&exclude, "exclude", "", "comma-separated list of function patterns to exclude")
 	flag.BoolVar(&force, "f", false, "force overwrite existing explore directories")
-	flag.StringVar(&funcs, "funcs", "", "comma-separated list of functions to parse")
+	flag.StringVar(&funcs, "funcs", "", `comma-separated list of function patterns to parse; glob patterns (e.g. "foo*") or regular expressions prefixed with "re:" (e.g. "re:^_ZN3foo"), matching either mangled or demangled names`)
+	flag.IntVar(&minBlocks, "min-blocks", 0, "minimum number of basic blocks of functions to parse")
+	flag.BoolVar(&fullModule, "module", false, "present LLVM IR assembly of the whole module in the LLVM pane")
+	flag.StringVar(&profilePath, "profile", "", "block count file of a profiling run, with one 'FUNC BLOCK COUNT' per line")
 	flag.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	flag.IntVar(&skipBefore, "skip-before", 0, "skip substeps before merge of functions with at least this many recovered primitives (0 never skips)")
 	flag.StringVar(&style, "style", "vs", "style used for syntax highlighting (borland, monokai, vs, ...)")
 	flag.Usage = usage
 	flag.Parse()
@@ -95,62 +182,125 @@ func main() {
 	default:
 		llPaths = flag.Args()
 	}
-	// Parse functions specified by the `-funcs` flag.
-	funcNames := make(map[string]bool)
-	for _, funcName := range strings.Split(funcs, ",") {
-		funcName = strings.TrimSpace(funcName)
-		if len(funcName) == 0 {
-			continue
-		}
-		funcNames[funcName] = true
+	// Parse function filters specified by the `-funcs`, `-exclude` and
+	// `-min-blocks` flags.
+	filter, err := newFuncFilter(funcs, exclude, minBlocks)
+	if err != nil {
+		log.Fatalf("%+v", err)
 	}
 	if quiet {
 		// Mute debug messages if `-q` is set.
 		dbg.SetOutput(ioutil.Discard)
 	}
+	// Parse block counts specified by the `-profile` flag.
+	var counts blockCounts
+	if len(profilePath) > 0 {
+		if counts, err = parseBlockCounts(profilePath); err != nil {
+			log.Fatalf("%+v", err)
+		}
+	}
 
 	// Generation visualization.
 	for _, llPath := range llPaths {
-		// Parse LLVM IR module.
-		e := newExplorer(llPath, style)
-		m, err := parseModule(llPath)
-		if err != nil {
+		if _, err := exploreFile(llPath, style, filter, fullModule, skipBefore, counts, force); err != nil {
 			log.Fatalf("%+v", err)
 		}
-		e.m = m
-		if len(m.Funcs) == 0 {
-			warn.Printf("no functions in module %q", llPath)
-			continue
-		}
-		// Parse debug LLVM IR module if present.
-		llDbgPath := pathutil.TrimExt(llPath) + "_dbg.ll"
-		if osutil.Exists(llDbgPath) {
-			dbg, err := parseModule(llDbgPath)
-			if err != nil {
-				log.Fatalf("%+v", err)
-			}
-			e.dbg = dbg
-		}
-		// Generate HTML visualizations.
-		if err := e.explore(funcNames, force); err != nil {
-			log.Fatalf("%+v", err)
+	}
+}
+
+// exploreFile generates an HTML visualization of the control flow analysis
+// performed on each function of the given LLVM IR assembly file, and returns
+// the explorer used to generate the visualization.
+//
+// - llPath is the path to the LLVM IR assembly file; or "-" for standard
+//   input.
+//
+// - style is the style used for syntax highlighting.
+//
+// - filter specifies the set of functions for which to generate
+//   visualizations.
+//
+// - fullModule specifies whether to present the LLVM IR assembly of the whole
+//   module in the LLVM pane.
+//
+// - skipBefore specifies the minimum number of recovered control flow
+//   primitives of functions for which to skip the substeps before merge; or 0
+//   to never skip.
+//
+// - counts specifies the execution count of each basic block, as recorded by a
+//   profiling run; or nil if not present.
+//
+// - force specifies whether to force overwrite existing explore directories.
+func exploreFile(llPath, style string, filter *funcFilter, fullModule bool, skipBefore int, counts blockCounts, force bool) (*explorer, error) {
+	e, err := loadModule(llPath, style, fullModule)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	e.skipBefore = skipBefore
+	e.counts = counts
+	if len(e.m.Funcs) == 0 {
+		warn.Printf("no functions in module %q", llPath)
+		return e, nil
+	}
+	// Generate HTML visualizations.
+	if err := e.explore(filter, force); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return e, nil
+}
+
+// loadModule parses the given LLVM IR assembly file, and its debug LLVM IR
+// module if present, and returns an explorer of the module.
+//
+// - llPath is the path to the LLVM IR assembly file; or "-" for standard
+//   input.
+//
+// - style is the style used for syntax highlighting.
+//
+// - fullModule specifies whether to present the LLVM IR assembly of the whole
+//   module in the LLVM pane.
+func loadModule(llPath, style string, fullModule bool) (*explorer, error) {
+	// Parse LLVM IR module.
+	e := newExplorer(llPath, style)
+	e.fullModule = fullModule
+	m, err := parseModule(llPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	e.m = m
+	var funcNames []string
+	for _, f := range m.Funcs {
+		funcNames = append(funcNames, f.Name())
+	}
+	e.slugs = funcSlugs(funcNames)
+	// Parse debug LLVM IR module if present.
+	llDbgPath := pathutil.TrimExt(llPath) + "_dbg.ll"
+	if osutil.Exists(llDbgPath) {
+		dbg, err := parseModule(llDbgPath)
+		if err != nil {
+			return nil, errors.WithStack(err)
 		}
+		e.dbg = dbg
 	}
+	return e, nil
 }
 
 // explore generates an HTML visualization of the control flow analysis
 // performed on each function of the given LLVM IR module.
 //
-// - funcNames specifies the set of function names for which to generate
-//   visualizations. When funcNames is emtpy, visualizations are generated for
-//   all function definitions of the module.
+// - filter specifies the set of functions for which to generate
+//   visualizations.
 //
 // - force specifies whether to force overwrite existing explore directories.
-func (e *explorer) explore(funcNames map[string]bool, force bool) error {
-	// Get functions set by `-funcs` or all functions if `-funcs` not used.
+func (e *explorer) explore(filter *funcFilter, force bool) error {
+	// Get function definitions included by the function filter.
 	var funcs []*ir.Func
 	for _, f := range e.m.Funcs {
-		if len(funcNames) > 0 && !funcNames[f.Name()] {
+		// Skip function declarations.
+		if len(f.Blocks) == 0 {
+			continue
+		}
+		if !filter.match(f) {
 			dbg.Printf("skipping function %q", f.Name())
 			continue
 		}
@@ -161,21 +311,26 @@ func (e *explorer) explore(funcNames map[string]bool, force bool) error {
 	if err := e.init(force); err != nil {
 		return errors.WithStack(err)
 	}
-	// Generate control flow graphs in DOT format.
-	if err := e.outputCFGs(funcNames); err != nil {
+	// Create control flow graph directory.
+	if err := e.createDOTDir(force); err != nil {
 		return errors.WithStack(err)
 	}
+	if len(funcs) == 0 {
+		warn.Printf("no functions in module %q matching function filter", e.llPath)
+	}
 	// Generate a visualization of the control flow analysis performed on each
 	// function.
+	visualized := make(map[string]bool)
 	for _, f := range funcs {
-		// Skip function declarations.
-		if len(f.Blocks) == 0 {
-			continue
-		}
 		// Generate visualization for the given function.
 		if err := e.outputFuncVisualization(f); err != nil {
 			return errors.WithStack(err)
 		}
+		visualized[f.Name()] = true
+	}
+	// Generate index page with call graph of module.
+	if err := e.outputIndex(visualized); err != nil {
+		return errors.WithStack(err)
 	}
 	return nil
 }
@@ -185,8 +340,16 @@ func (e *explorer) explore(funcNames map[string]bool, force bool) error {
 //
 // - f is the function to visualize.
 func (e *explorer) outputFuncVisualization(f *ir.Func) error {
-	// Generate control flow primtives in JSON format.
+	// Generate control flow graph in DOT format.
 	funcName := f.Name()
+	g, err := newCFG(f)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := e.outputCFG(g); err != nil {
+		return errors.WithStack(err)
+	}
+	// Generate control flow primtives in JSON format.
 	if err := e.outputPrims(funcName); err != nil {
 		return errors.WithStack(err)
 	}
@@ -197,34 +360,121 @@ func (e *explorer) outputFuncVisualization(f *ir.Func) error {
 		return errors.WithStack(err)
 	}
 	// Parse original C source code.
-	cSource, err := e.parseC()
+	cSource, cSearch, err := e.parseC()
 	if err != nil {
 		return errors.WithStack(err)
 	}
 	hasC := len(cSource) > 0
-	npages := 1 + 2*len(prims)
-	for page := 1; page <= npages; page++ {
+	// Compute dominator tree and post-dominator tree of function.
+	dom := dominators(g)
+	postDom := postDominators(g)
+	// Compute loop nesting forest of function.
+	loops := naturalLoops(g, dom)
+	if len(loops) > 0 {
+		if err := e.outputLoops(g, loops); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// Compute residual control flow graph, which remains after merging all
+	// recovered control flow primitives.
+	r := newResidual(g, prims)
+	if r.isComplete() {
+		r = nil
+	} else {
+		warn.Printf("control flow recovery of function %q incomplete; %d nodes remaining", funcName, len(r.nodes))
+	}
+	// Compute execution profile of function from block counts or branch
+	// weights; or nil if not present.
+	prof := newProfile(g, e.counts[funcName])
+	// Reconstructed Go source code to compare against in the diff view of the
+	// Go pane; i.e. the Go source code of step 0 or of the latest step before
+	// merge.
+	var baseGoSource, baseGoName string
+	summary := &funcSummary{
+		Name:    funcName,
+		Slug:    e.funcSlug(funcName),
+		NBlocks: len(f.Blocks),
+		Prims:   prims,
+	}
+	if r != nil {
+		summary.NResidual = len(r.nodes)
+	} else {
+		summary.NResidual = 1
+	}
+	// Intermediate step presented on each overview page; the substeps before
+	// merge are omitted for functions with at least `-skip-before` recovered
+	// control flow primitives.
+	skipBefore := e.skipBefore > 0 && len(prims) >= e.skipBefore
+	pages := stepPages(len(prims), skipBefore)
+	if err := e.outputSteps(funcName, pages, prims); err != nil {
+		return errors.WithStack(err)
+	}
+	for i, sp := range pages {
 		// Output overview.
-		//
-		//    page 1: step 0
-		//    page 2: step 1a
-		//    page 3: step 1b
-		//    page 4: step 2a
-		//    page 5: step 2b
-		//    ...
-		step := page / 2
-		subStep := subStepFromPage(page)
-		if err := e.outputOverview(funcName, page, npages, step, subStep); err != nil {
+		page := i + 1
+		step, subStep := sp.step, sp.subStep
+		// Explain why restructuring stopped on the last page.
+		var (
+			banner  string
+			lastRes *residual
+		)
+		if page == len(pages) && r != nil {
+			banner = r.desc()
+			lastRes = r
+		}
+		if err := e.outputOverview(funcName, pages, page, prims, banner, hasC, cSearch); err != nil {
 			return errors.WithStack(err)
 		}
 		// Output control flow analysis.
-		if err := e.outputCFA(funcName, step, subStep); err != nil {
+		var prim *primitive.Primitive
+		if step > 0 {
+			prim = prims[step-1]
+		}
+		loopSums := loopSummaries(g, loops, primBlocks(f, prims, step))
+		if prof != nil {
+			if err := e.outputProfile(g, prof, prims, step, subStep); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if err := e.outputCFA(funcName, prim, loopSums, len(loops) > 0, prof != nil, lastRes, step, subStep); err != nil {
 			return errors.WithStack(err)
 		}
 		// Output reconstructed Go source code.
-		if err := e.outputGo(funcName, prims, step, subStep); err != nil {
+		goSource, err := e.outputGo(funcName, prims, step, subStep, baseGoSource, baseGoName)
+		if err != nil {
 			return errors.WithStack(err)
 		}
+		if subStep != "b" || skipBefore {
+			baseGoSource = goSource
+			baseGoName = fmt.Sprintf("step %d%s", step, subStep)
+		}
+		if subStep != "a" {
+			summary.GoSources = append(summary.GoSources, goSource)
+		}
+	}
+	// Output transcript narrating each step.
+	if err := e.outputTranscript(funcName, pages, prims, r); err != nil {
+		return errors.WithStack(err)
+	}
+	// Output control tree of recovered control flow primitives.
+	if err := e.outputControlTree(g, pages, prims, hasC); err != nil {
+		return errors.WithStack(err)
+	}
+	// Output depth-first search spanning tree with classified edges.
+	if err := e.outputDFS(g, dom); err != nil {
+		return errors.WithStack(err)
+	}
+	// Output placeholder of execution trace page, replaced by `explore exec`.
+	if err := e.outputExecPlaceholder(f); err != nil {
+		return errors.WithStack(err)
+	}
+	// Output interval analysis of the derived sequence of graphs.
+	if err := e.outputIntervals(g, hasC); err != nil {
+		return errors.WithStack(err)
+	}
+	// Output machine-readable summary, used to compare explorations.
+	if err := e.outputSummary(summary); err != nil {
+		return errors.WithStack(err)
 	}
 	nsteps := len(prims)
 	for step := 0; step <= nsteps; step++ {
@@ -240,36 +490,40 @@ func (e *explorer) outputFuncVisualization(f *ir.Func) error {
 				return errors.WithStack(err)
 			}
 		}
-		// Output LLVM IR assembly.
-		if err := e.outputLLVM(funcName, prim, step); err != nil {
+		// Output LLVM IR assembly, highlighting irreducible regions on the last
+		// step.
+		var irrBlocks []string
+		if step == nsteps && r != nil {
+			irrBlocks = r.regionBlocks()
+		}
+		if err := e.outputLLVM(funcName, prim, irrBlocks, prof, step); err != nil {
+			return errors.WithStack(err)
+		}
+		// Output dominator tree and post-dominator tree.
+		blocks := primBlocks(f, prims, step)
+		if err := e.outputDom(g, dom, postDom, blocks, step); err != nil {
 			return errors.WithStack(err)
 		}
 	}
 	return nil
 }
 
-// outputCFGs outputs the control flow graphs of the given LLVM IR module by
-// running the ll2dot tool.
+// outputCFG outputs the control flow graph of the given function in Graphviz
+// DOT format, and an image representation of the control flow graph.
 //
-// - funcNames specifies the set of function names for which to generate
-//   visualizations. When funcNames is emtpy, visualizations are generated for
-//   all function definitions of the module.
-func (e *explorer) outputCFGs(funcNames map[string]bool) error {
-	var args []string
-	if len(funcNames) > 0 {
-		var funcs []string
-		for funcName := range funcNames {
-			funcs = append(funcs, funcName)
-		}
-		sort.Strings(funcs)
-		args = append(args, "-funcs", strings.Join(funcs, ","))
+// - g is the control flow graph of the analyzed function.
+func (e *explorer) outputCFG(g *cfg) error {
+	p := e.paths(g.f.Name())
+	// Create graph directory of function.
+	if err := os.MkdirAll(p.graphDir(), 0755); err != nil {
+		return errors.WithStack(err)
 	}
-	args = append(args, "-f", "-img", e.llPath)
-	cmd := exec.Command("ll2dot2", args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	dotPath := p.cfgDOT()
+	dbg.Printf("creating file %q", dotPath)
+	if err := ioutil.WriteFile(dotPath, []byte(cfgDOT(g)), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := outputImg(dotPath, p.stepPNG(0, "")); err != nil {
 		return errors.WithStack(err)
 	}
 	return nil
@@ -278,11 +532,8 @@ func (e *explorer) outputCFGs(funcNames map[string]bool) error {
 // outputPrims outputs the recovered control flow primitives of the given LLVM
 // IR module by running the restructure tool.
 func (e *explorer) outputPrims(funcName string) error {
-	jsonName := funcName + ".json"
-	jsonPath := filepath.Join(e.dotDir, jsonName)
-	dotName := funcName + ".dot"
-	dotPath := filepath.Join(e.dotDir, dotName)
-	cmd := exec.Command("restructure2", "-steps", "-img", "-indent", "-o", jsonPath, dotPath)
+	p := e.paths(funcName)
+	cmd := exec.Command("restructure2", "-steps", "-img", "-indent", "-o", p.primsJSON(), p.cfgDOT())
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
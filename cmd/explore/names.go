@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ianlancetaylor/demangle"
+)
+
+// displayName returns the human-readable name of the given function; i.e. the
+// demangled name of C++ and Rust functions, and the function name otherwise.
+func displayName(funcName string) string {
+	return demangle.Filter(funcName)
+}
+
+// maxSlugLen is the maximum length of function name slugs.
+const maxSlugLen = 64
+
+// reSlug is a regular expression matching function names which are safe to use
+// as is in file names.
+var reSlug = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]*$`)
+
+// reUnsafe is a regular expression matching characters which are unsafe to use
+// in file names.
+var reUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// reservedNames is the set of device names reserved on Windows, which are
+// invalid as file names regardless of extension (e.g. "con" or "con.txt").
+var reservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true,
+	"com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true,
+	"lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// isReservedSlug reports whether the given slug is invalid as a file name on
+// Windows; i.e. a reserved device name, with or without extension, or a name
+// ending with a dot.
+func isReservedSlug(slug string) bool {
+	if strings.HasSuffix(slug, ".") {
+		return true
+	}
+	name := strings.ToLower(slug)
+	if pos := strings.Index(name, "."); pos != -1 {
+		name = name[:pos]
+	}
+	return reservedNames[name]
+}
+
+// funcSlugs returns a safe and collision-free slug of each of the given
+// function names, for use in output file names; indexed by function name.
+//
+// Function names which are safe to use in file names on every filesystem and no
+// longer than maxSlugLen characters are used as is. Other function names (e.g.
+// reserved device names such as "con" on Windows) are sanitized, truncated and
+// suffixed with a hash of the function name; e.g.
+//
+//    "foo bar" -> "foo_bar_a3f5c2d1"
+//
+// Slugs are compared case-insensitively, as file names are on macOS and
+// Windows. Function names which differ from a preceding one only by case are
+// suffixed with a hash, and slugs which would collide with the slug of a
+// preceding function name are suffixed with a sequence number.
+func funcSlugs(funcNames []string) map[string]string {
+	slugs := make(map[string]string)
+	// Used slugs, in lower case.
+	used := make(map[string]bool)
+	// Reserve slugs of function names used as is, so that these remain stable
+	// regardless of the other function names of the module.
+	for _, funcName := range funcNames {
+		if len(funcName) <= maxSlugLen && reSlug.MatchString(funcName) && !isReservedSlug(funcName) && !used[strings.ToLower(funcName)] {
+			slugs[funcName] = funcName
+			used[strings.ToLower(funcName)] = true
+		}
+	}
+	for _, funcName := range funcNames {
+		if _, ok := slugs[funcName]; ok {
+			continue
+		}
+		hash := fmt.Sprintf("%x", sha1.Sum([]byte(funcName)))[:8]
+		prefix := reUnsafe.ReplaceAllString(funcName, "_")
+		if max := maxSlugLen - len("_") - len(hash); len(prefix) > max {
+			prefix = prefix[:max]
+		}
+		base := fmt.Sprintf("_%s_%s", prefix, hash)
+		if reSlug.MatchString(prefix) && !isReservedSlug(prefix+"_"+hash) {
+			base = fmt.Sprintf("%s_%s", prefix, hash)
+		}
+		slug := base
+		for i := 2; used[strings.ToLower(slug)]; i++ {
+			slug = fmt.Sprintf("%s_%d", base, i)
+		}
+		slugs[funcName] = slug
+		used[strings.ToLower(slug)] = true
+	}
+	return slugs
+}
+
+// funcSlug returns the slug of the given function name, for use in output file
+// names.
+func (e *explorer) funcSlug(funcName string) string {
+	if slug, ok := e.slugs[funcName]; ok {
+		return slug
+	}
+	return funcSlugs([]string{funcName})[funcName]
+}
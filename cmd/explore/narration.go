@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/mewmew/lnp/pkg/cfa/primitive"
+	"github.com/pkg/errors"
+)
+
+// primNames maps from primitive name to plain-language name, including
+// indefinite article.
+var primNames = map[string]string{
+	"seq":       "a sequence",
+	"if":        "an if-then",
+	"if_else":   "an if-then-else",
+	"if_return": "an if-then with early return",
+	"pre_loop":  "a pre-test loop",
+	"post_loop": "a post-test loop",
+	"switch":    "a switch",
+}
+
+// narrate returns a plain-language description of the given intermediate step
+// of the control flow analysis; e.g.
+//
+//    Step 2b: nodes %3 (cond), %4 (body) and %5 (exit) matched an if-then;
+//    they were merged into node %3.
+//
+// - funcName is the function name of the analyzed function.
+//
+// - prim is the recovered control flow primitive; or nil on step 0.
+//
+// - step is the intermediate step of the control flow analysis.
+//
+// - subStep specifies whether the intermediate step is before or after merge,
+//   where "a" specifies before and "b" after.
+func narrate(funcName string, prim *primitive.Primitive, step int, subStep string) string {
+	if prim == nil {
+		return fmt.Sprintf("Step 0: the original control flow graph of function %s, before recovering any control flow primitives.", displayName(funcName))
+	}
+	primName, ok := primNames[prim.Prim]
+	if !ok {
+		primName = fmt.Sprintf("a %q primitive", prim.Prim)
+	}
+	var roles []string
+	for role := range prim.Nodes {
+		roles = append(roles, role)
+	}
+	// Sort roles in control flow order; e.g. cond, body, exit.
+	sort.Slice(roles, func(i, j int) bool {
+		ri, rj := roleRank(roles[i]), roleRank(roles[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return roles[i] < roles[j]
+	})
+	var nodes []string
+	for _, role := range roles {
+		nodes = append(nodes, fmt.Sprintf("%%%s (%s)", prim.Nodes[role], role))
+	}
+	switch subStep {
+	case "a":
+		return fmt.Sprintf("Step %d%s: %s %s %s; %s will be merged into node %%%s.", step, subStep, pluralNodes(nodes), pluralVerb(nodes, "matches", "match"), primName, pluralVerb(nodes, "it", "they"), prim.Entry)
+	default:
+		return fmt.Sprintf("Step %d%s: %s matched %s; %s merged into node %%%s.", step, subStep, pluralNodes(nodes), primName, pluralVerb(nodes, "it was", "they were"), prim.Entry)
+	}
+}
+
+// roleRank returns the rank of the given primitive node role, used to sort
+// roles in control flow order.
+func roleRank(role string) int {
+	switch role {
+	case "entry", "cond":
+		return 0
+	case "exit":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// pluralNodes returns the given list of node descriptions as an English
+// enumeration; e.g. "nodes %3 (cond), %4 (body) and %5 (exit)".
+func pluralNodes(nodes []string) string {
+	switch len(nodes) {
+	case 0:
+		return "no nodes"
+	case 1:
+		return "node " + nodes[0]
+	default:
+		return fmt.Sprintf("nodes %s and %s", strings.Join(nodes[:len(nodes)-1], ", "), nodes[len(nodes)-1])
+	}
+}
+
+// pluralVerb returns the singular or plural form of a verb or pronoun, based on
+// the number of nodes.
+func pluralVerb(nodes []string, singular, plural string) string {
+	if len(nodes) == 1 {
+		return singular
+	}
+	return plural
+}
+
+// outputTranscript outputs a plain-text transcript of the control flow analysis
+// of the given function, with one line of narration per intermediate step.
+//
+// - funcName is the function name of the analyzed function.
+//
+// - pages specifies the intermediate step presented on each overview page.
+//
+// - prims is the list of recovered control flow primitives.
+//
+// - r is the residual control flow graph; or nil if the control flow graph was
+//   reduced to a single node.
+func (e *explorer) outputTranscript(funcName string, pages []stepPage, prims []*primitive.Primitive, r *residual) error {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "Control flow analysis of function %s\n\n", displayName(funcName))
+	for _, sp := range pages {
+		var prim *primitive.Primitive
+		if sp.step > 0 {
+			prim = prims[sp.step-1]
+		}
+		buf.WriteString(narrate(funcName, prim, sp.step, sp.subStep))
+		buf.WriteString("\n")
+	}
+	if r != nil {
+		fmt.Fprintf(buf, "\n%s\n", r.desc())
+	}
+	p := e.paths(funcName)
+	txtPath := p.path(p.transcriptTxt())
+	dbg.Printf("creating file %q", txtPath)
+	if err := ioutil.WriteFile(txtPath, []byte(buf.String()), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mewkiz/pkg/pathutil"
+	"github.com/pkg/errors"
+)
+
+func optUsage() {
+	const use = `
+Compare the control flow analysis of a C source file compiled using different
+optimization pipelines.
+
+Usage:
+
+	explore opt [OPTION]... FILE.c
+
+Flags:
+`
+	fmt.Fprintln(os.Stderr, use[1:])
+}
+
+// optMain compares the control flow analysis of a C source file compiled using
+// different optimization pipelines, as specified by the command line arguments
+// of the `explore opt` command.
+func optMain(args []string) {
+	// Parse command line arguments.
+	var (
+		// exclude represents a comma-separated list of function patterns to
+		// exclude.
+		exclude string
+		// force specifies whether to force overwrite existing explore
+		// directories.
+		force bool
+		// funcs represents a comma-separated list of function patterns to parse.
+		funcs string
+		// minBlocks specifies the minimum number of basic blocks of functions to
+		// parse.
+		minBlocks int
+		// fullModule specifies whether to present the LLVM IR assembly of the
+		// whole module in the LLVM pane.
+		fullModule bool
+		// pipelines represents a comma-separated list of optimization
+		// pipelines.
+		pipelines string
+		// quiet specifies whether to suppress non-error messages.
+		quiet bool
+		// skipBefore specifies the minimum number of recovered control flow
+		// primitives of functions for which to skip the substeps before merge;
+		// or 0 to never skip.
+		skipBefore int
+		// style specifies the style used for syntax highlighting.
+		style string
+	)
+	fs := flag.NewFlagSet("opt", flag.ExitOnError)
+	fs.StringVar(&exclude, "exclude", "", "comma-separated list of function patterns to exclude")
+	fs.BoolVar(&force, "f", false, "force overwrite existing explore directories")
+	fs.StringVar(&funcs, "funcs", "", `comma-separated list of function patterns to parse; glob patterns (e.g. "foo*") or regular expressions prefixed with "re:" (e.g. "re:^_ZN3foo"), matching either mangled or demangled names`)
+	fs.IntVar(&minBlocks, "min-blocks", 0, "minimum number of basic blocks of functions to parse")
+	fs.BoolVar(&fullModule, "module", false, "present LLVM IR assembly of the whole module in the LLVM pane")
+	fs.StringVar(&pipelines, "pipelines", "O0,mem2reg,O2", "comma-separated list of optimization pipelines; clang optimization levels (O0, O1, O2, O3, Os, Oz) or '+'-separated opt passes (e.g. mem2reg+simplifycfg)")
+	fs.BoolVar(&quiet, "q", false, "suppress non-error messages")
+	fs.IntVar(&skipBefore, "skip-before", 0, "skip substeps before merge of functions with at least this many recovered primitives (0 never skips)")
+	fs.StringVar(&style, "style", "vs", "style used for syntax highlighting (borland, monokai, vs, ...)")
+	fs.Usage = func() {
+		optUsage()
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	cPath := fs.Arg(0)
+	var ps []string
+	for _, pipeline := range strings.Split(pipelines, ",") {
+		pipeline = strings.TrimSpace(pipeline)
+		if len(pipeline) == 0 {
+			continue
+		}
+		ps = append(ps, pipeline)
+	}
+	if quiet {
+		// Mute debug messages if `-q` is set.
+		dbg.SetOutput(ioutil.Discard)
+	}
+	filter, err := newFuncFilter(funcs, exclude, minBlocks)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	if err := compareOpts(cPath, ps, style, filter, fullModule, skipBefore, force); err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
+
+// optExploration is the exploration of a C source file compiled using a given
+// optimization pipeline.
+type optExploration struct {
+	// Optimization pipeline.
+	pipeline string
+	// Explore output directory.
+	outputDir string
+	// Function summaries; indexed by function name.
+	summaries map[string]*funcSummary
+}
+
+// optComparison is the comparison of a function compiled using different
+// optimization pipelines.
+type optComparison struct {
+	// Function name.
+	Name string
+	// Link to the comparison page of the function.
+	Link string
+	// Exploration of the function for each optimization pipeline.
+	Levels []optLevel
+}
+
+// optLevel is the exploration of a function compiled using a given
+// optimization pipeline.
+type optLevel struct {
+	// Optimization pipeline.
+	Pipeline string
+	// Specifies whether the function is present in the exploration.
+	Present bool
+	// Link to the visualization of the function.
+	Link string
+	// Number of basic blocks.
+	NBlocks int
+	// Number of recovered control flow primitives.
+	NPrims int
+	// Number of nodes remaining after control flow recovery.
+	NResidual int
+	// Final reconstructed Go source code.
+	GoSource string
+}
+
+// compareOpts compares the control flow analysis of the given C source file
+// compiled using the specified optimization pipelines.
+//
+// - cPath is the path to the C source file.
+//
+// - pipelines is the list of optimization pipelines.
+//
+// - style is the style used for syntax highlighting.
+//
+// - filter specifies the set of functions for which to generate
+//   visualizations.
+//
+// - fullModule specifies whether to present the LLVM IR assembly of the whole
+//   module in the LLVM pane.
+//
+// - skipBefore specifies the minimum number of recovered control flow
+//   primitives of functions for which to skip the substeps before merge; or 0
+//   to never skip.
+//
+// - force specifies whether to force overwrite existing explore directories.
+func compareOpts(cPath string, pipelines []string, style string, filter *funcFilter, fullModule bool, skipBefore int, force bool) error {
+	// Explore LLVM IR of each optimization pipeline.
+	var explorations []*optExploration
+	for _, pipeline := range pipelines {
+		llPath, err := compilePipeline(cPath, pipeline)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		e, err := exploreFile(llPath, style, filter, fullModule, skipBefore, nil, force)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		summaries, err := parseSummaries(e.outputDir)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		exploration := &optExploration{
+			pipeline:  pipeline,
+			outputDir: e.outputDir,
+			summaries: summaries,
+		}
+		explorations = append(explorations, exploration)
+	}
+	// Output comparison of optimization pipelines.
+	e := &explorer{
+		outputDir: pathutil.TrimExt(cPath) + "_opt_explore",
+		style:     style,
+	}
+	if err := e.init(force); err != nil {
+		return errors.WithStack(err)
+	}
+	nameSet := make(map[string]bool)
+	for _, exploration := range explorations {
+		for name := range exploration.summaries {
+			nameSet[name] = true
+		}
+	}
+	var names []string
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	e.slugs = funcSlugs(names)
+	var comps []*optComparison
+	for _, name := range names {
+		comp := &optComparison{
+			Name: displayName(name),
+			Link: e.paths(name).optPage(),
+		}
+		for _, exploration := range explorations {
+			level := optLevel{Pipeline: exploration.pipeline}
+			if summary, ok := exploration.summaries[name]; ok {
+				level.Present = true
+				level.NBlocks = summary.NBlocks
+				level.NPrims = len(summary.Prims)
+				level.NResidual = summary.NResidual
+				level.GoSource = summary.finalGoSource()
+				relDir, err := filepath.Rel(e.outputDir, exploration.outputDir)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				p := &funcPaths{slug: summary.Slug}
+				level.Link = filepath.ToSlash(filepath.Join(relDir, p.overviewPage(1)))
+			}
+			comp.Levels = append(comp.Levels, level)
+		}
+		if err := e.outputOpt(comp); err != nil {
+			return errors.WithStack(err)
+		}
+		comps = append(comps, comp)
+	}
+	return e.outputOptIndex(filepath.Base(cPath), comps)
+}
+
+// reOptLevel is a regular expression matching clang optimization levels.
+var reOptLevel = regexp.MustCompile(`^O[0-3sz]$`)
+
+// compilePipeline compiles the given C source file into LLVM IR assembly using
+// the specified optimization pipeline, and returns the path of the LLVM IR
+// assembly file. A debug version of the LLVM IR assembly file (with DWARF debug
+// info) is also generated, to locate the lines of the C source file associated
+// with each basic block.
+//
+// The optimization pipeline is either a clang optimization level (e.g. "O2"),
+// or a '+'-separated list of opt passes (e.g. "mem2reg+simplifycfg) applied to
+// unoptimized LLVM IR.
+func compilePipeline(cPath, pipeline string) (string, error) {
+	base := fmt.Sprintf("%s_%s", pathutil.TrimExt(cPath), pipeline)
+	llPath := base + ".ll"
+	llDbgPath := base + "_dbg.ll"
+	for _, outPath := range []string{llPath, llDbgPath} {
+		args := []string{"-S", "-emit-llvm"}
+		if outPath == llDbgPath {
+			args = append(args, "-g")
+		}
+		var passes []string
+		if reOptLevel.MatchString(pipeline) {
+			args = append(args, "-"+pipeline)
+		} else {
+			// Remove optnone attribute to allow opt passes to optimize
+			// unoptimized LLVM IR.
+			args = append(args, "-O0", "-Xclang", "-disable-O0-optnone")
+			passes = strings.Split(pipeline, "+")
+		}
+		args = append(args, "-o", outPath, cPath)
+		if err := runCommand("clang", args...); err != nil {
+			return "", errors.WithStack(err)
+		}
+		if len(passes) == 0 {
+			continue
+		}
+		optArgs := []string{"-S"}
+		for _, pass := range passes {
+			optArgs = append(optArgs, "--"+pass)
+		}
+		optArgs = append(optArgs, "-o", outPath, outPath)
+		if err := runCommand("opt", optArgs...); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+	return llPath, nil
+}
+
+// runCommand runs the given command, forwarding its output to standard output
+// and standard error.
+func runCommand(name string, args ...string) error {
+	dbg.Printf("running %s %s", name, strings.Join(args, " "))
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// parseOptTemplates parses the HTML templates used to compare optimization
+// pipelines.
+func (e *explorer) parseOptTemplates() error {
+	for _, tmplName := range []string{"opt.tmpl", "opt_index.tmpl"} {
+		tmplPath := filepath.Join(e.repoDir, "cmd/explore", tmplName)
+		ts, err := template.ParseFiles(tmplPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		switch tmplName {
+		case "opt.tmpl":
+			e.optTmpl = ts.Lookup(tmplName)
+		case "opt_index.tmpl":
+			e.optIndexTmpl = ts.Lookup(tmplName)
+		}
+	}
+	return nil
+}
+
+// outputOpt outputs the comparison of a function compiled using different
+// optimization pipelines.
+func (e *explorer) outputOpt(comp *optComparison) error {
+	htmlContent := &bytes.Buffer{}
+	data := map[string]interface{}{
+		"Comp": comp,
+	}
+	if err := e.optTmpl.Execute(htmlContent, data); err != nil {
+		return errors.WithStack(err)
+	}
+	htmlPath := filepath.Join(e.outputDir, comp.Link)
+	dbg.Printf("creating file %q", htmlPath)
+	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// outputOptIndex outputs the index page of the comparison of optimization
+// pipelines.
+//
+// - cName is the file name of the C source file.
+//
+// - comps is the comparison of each function.
+func (e *explorer) outputOptIndex(cName string, comps []*optComparison) error {
+	htmlContent := &bytes.Buffer{}
+	data := map[string]interface{}{
+		"CName": cName,
+		"Comps": comps,
+	}
+	if err := e.optIndexTmpl.Execute(htmlContent, data); err != nil {
+		return errors.WithStack(err)
+	}
+	htmlPath := filepath.Join(e.outputDir, "index.html")
+	dbg.Printf("creating file %q", htmlPath)
+	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -30,25 +30,27 @@ func (e *explorer) parseCTemplate() error {
 	return nil
 }
 
-// parseC parses the original C source file.
-func (e *explorer) parseC() (string, error) {
+// parseC parses the original C source file. The returned list of search
+// attempts describes how the C source file was located; or why it could not be
+// located, in which case the returned C source is empty.
+func (e *explorer) parseC() (string, []string, error) {
 	// Locate original C source file.
 	m := e.m
 	if e.dbg != nil {
 		m = e.dbg
 	}
-	cPath, ok := findCPath(e.llPath, m)
+	cPath, search, ok := findCPath(e.llPath, m)
 	if !ok {
 		// Early exit if original C source file is not present.
-		return "", nil
+		return "", search, nil
 	}
 	dbg.Printf("reading file %q", cPath)
 	buf, err := ioutil.ReadFile(cPath)
 	if err != nil {
-		return "", errors.WithStack(err)
+		return "", search, errors.WithStack(err)
 	}
 	cSource := string(buf)
-	return cSource, nil
+	return cSource, search, nil
 }
 
 // outputC outputs the original C source file of the LLVM IR assembly,
@@ -124,15 +126,15 @@ func (e *explorer) outputCHTML(cSource, funcName string, lines [][2]int, step in
 	// Generate C HTML page.
 	htmlContent := &bytes.Buffer{}
 	data := map[string]interface{}{
-		"FuncName": funcName,
+		"FuncName": displayName(funcName),
 		"Style":    e.style,
 		"CCode":    template.HTML(cCode.String()),
 	}
 	if err := e.cTmpl.Execute(htmlContent, data); err != nil {
 		return errors.WithStack(err)
 	}
-	htmlName := fmt.Sprintf("%s_step_%04d_c.html", funcName, step)
-	htmlPath := filepath.Join(e.outputDir, htmlName)
+	p := e.paths(funcName)
+	htmlPath := p.path(p.cPage(step))
 	dbg.Printf("creating file %q", htmlPath)
 	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
 		return errors.WithStack(err)
@@ -144,25 +146,44 @@ func (e *explorer) outputCHTML(cSource, funcName string, lines [][2]int, step in
 // produce the given LLVM IR module. It tries to locate the C source file
 // firstly based on the DWARF metadata debug info DIFile of the parsed module,
 // secondly based on the source_filename top-level entity of the parsed module,
-// and lastly based on the LLVM IR assembly path.
+// and lastly based on the LLVM IR assembly path. The returned list of search
+// attempts describes the outcome of each location tried.
 //
 // - llPath is the path to the LLVM IR assembly file.
 //
 // - m is the parsed LLVM IR module (or the parsed debug module if present).
-func findCPath(llPath string, m *ir.Module) (string, bool) {
-	if md, ok := m.NamedMetadataDefs["llvm.dbg.cu"]; ok {
-		unit := md.Nodes[0].(*metadata.DICompileUnit)
-		cPath := filepath.Join(unit.File.Directory, unit.File.Filename)
-		return cPath, true
-	}
-	if len(m.SourceFilename) > 0 && osutil.Exists(m.SourceFilename) {
-		return m.SourceFilename, true
+func findCPath(llPath string, m *ir.Module) (string, []string, bool) {
+	var search []string
+	if md, ok := m.NamedMetadataDefs["llvm.dbg.cu"]; ok && len(md.Nodes) > 0 {
+		if unit, ok := md.Nodes[0].(*metadata.DICompileUnit); ok && unit.File != nil {
+			cPath := filepath.Join(unit.File.Directory, unit.File.Filename)
+			if osutil.Exists(cPath) {
+				search = append(search, fmt.Sprintf("DICompileUnit of !llvm.dbg.cu: found %q", cPath))
+				return cPath, search, true
+			}
+			search = append(search, fmt.Sprintf("DICompileUnit of !llvm.dbg.cu: %q does not exist", cPath))
+		} else {
+			search = append(search, "DICompileUnit of !llvm.dbg.cu: no DIFile present")
+		}
+	} else {
+		search = append(search, "DICompileUnit of !llvm.dbg.cu: no debug information present (compile with -g)")
+	}
+	switch {
+	case len(m.SourceFilename) == 0:
+		search = append(search, "source_filename: not present")
+	case osutil.Exists(m.SourceFilename):
+		search = append(search, fmt.Sprintf("source_filename: found %q", m.SourceFilename))
+		return m.SourceFilename, search, true
+	default:
+		search = append(search, fmt.Sprintf("source_filename: %q does not exist", m.SourceFilename))
 	}
 	cPath := pathutil.TrimExt(llPath) + ".c"
 	if osutil.Exists(cPath) {
-		return cPath, true
+		search = append(search, fmt.Sprintf("sibling of LLVM IR assembly: found %q", cPath))
+		return cPath, search, true
 	}
-	return "", false
+	search = append(search, fmt.Sprintf("sibling of LLVM IR assembly: %q does not exist", cPath))
+	return "", search, false
 }
 
 // findCHighlight returns the lines to highlight in the given function
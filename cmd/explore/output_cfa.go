@@ -2,11 +2,17 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"io/ioutil"
 	"path/filepath"
 
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/mewmew/lnp/pkg/cfa/primitive"
 	dircopy "github.com/otiai10/copy"
 	"github.com/pkg/errors"
 )
@@ -29,27 +35,40 @@ func (e *explorer) parseCFATemplate() error {
 //
 // - funcName is the function name of the analyzed function.
 //
+// - prim is the recovered control flow primitive; or nil if not present.
+//
+// - loops is the list of textual summaries of loops with a header among the
+//   basic blocks of the recovered control flow primitive.
+//
+// - hasLoops specifies whether the function contains loops.
+//
+// - hasProfile specifies whether the execution profile of the function is
+//   present.
+//
+// - r is the residual control flow graph, which remains after merging all
+//   recovered control flow primitives; or nil if not the last page or if the
+//   control flow graph was reduced to a single node.
+//
 // - step is the intermediate step of the control flow analysis.
 //
 // - subStep specifies whether the intermediate step is before or after merge,
 //   where "a" specifies before and "b" after (using lexicographic naming to
 //   have files be listed in the logical order).
-func (e *explorer) outputCFA(funcName string, step int, subStep string) error {
+func (e *explorer) outputCFA(funcName string, prim *primitive.Primitive, loops []string, hasLoops, hasProfile bool, r *residual, step int, subStep string) error {
 	// Copy control flow graph.
-	var cfgSrcName string
-	switch step {
-	case 0:
-		cfgSrcName = fmt.Sprintf("%s.png", funcName)
-	default:
-		cfgSrcName = fmt.Sprintf("%s_%04d%s.png", funcName, step, subStep)
-	}
-	cfgSrcPath := filepath.Join(e.dotDir, cfgSrcName)
-	cfgDstName := fmt.Sprintf("%s_step_%04d%s.png", funcName, step, subStep)
-	cfgDstPath := filepath.Join(e.outputDir, "img", cfgDstName)
+	p := e.paths(funcName)
+	cfgSrcPath := p.stepPNG(step, subStep)
+	cfgDstPath := p.path(p.cfgImg(step, subStep))
 	dbg.Printf("creating file %q", cfgDstPath)
 	dircopy.Copy(cfgSrcPath, cfgDstPath)
+	// Output residual control flow graph.
+	if r != nil {
+		if err := e.outputResidual(funcName, r); err != nil {
+			return errors.WithStack(err)
+		}
+	}
 	// Output visualization of control flow analysis in HTML format.
-	return e.outputCFAHTML(funcName, step, subStep)
+	return e.outputCFAHTML(funcName, prim, loops, hasLoops, hasProfile, r, step, subStep)
 }
 
 // outputCFAHTML outputs the control flow analysis in HTML format, highlighting
@@ -58,38 +77,135 @@ func (e *explorer) outputCFA(funcName string, step int, subStep string) error {
 //
 // - funcName is the function name of the analyzed function.
 //
+// - prim is the recovered control flow primitive; or nil if not present.
+//
+// - loops is the list of textual summaries of loops with a header among the
+//   basic blocks of the recovered control flow primitive.
+//
+// - hasLoops specifies whether the function contains loops.
+//
+// - hasProfile specifies whether the execution profile of the function is
+//   present.
+//
+// - r is the residual control flow graph, which remains after merging all
+//   recovered control flow primitives; or nil if not the last page or if the
+//   control flow graph was reduced to a single node.
+//
 // - step is the intermediate step of the control flow analysis.
 //
 // - subStep specifies whether the intermediate step is before or after merge,
 //   where "a" specifies before and "b" after (using lexicographic naming to
 //   have files be listed in the logical order).
-func (e *explorer) outputCFAHTML(funcName string, step int, subStep string) error {
+func (e *explorer) outputCFAHTML(funcName string, prim *primitive.Primitive, loops []string, hasLoops, hasProfile bool, r *residual, step int, subStep string) error {
 	// Description of intermediate step and substep.
 	var desc string
 	switch subStep {
 	case "a":
-		desc = fmt.Sprintf("Control flow graph of function %s, before merge in step %d.", funcName, step)
+		desc = fmt.Sprintf("Control flow graph of function %s, before merge in step %d.", displayName(funcName), step)
 	case "b":
-		desc = fmt.Sprintf("Control flow graph of function %s, after merge in step %d.", funcName, step)
+		desc = fmt.Sprintf("Control flow graph of function %s, after merge in step %d.", displayName(funcName), step)
 	default:
-		desc = fmt.Sprintf("Control flow graph of function %s.", funcName)
+		desc = fmt.Sprintf("Control flow graph of function %s.", displayName(funcName))
 	}
 	// Generate control flow analysis HTML page.
+	p := e.paths(funcName)
 	htmlContent := &bytes.Buffer{}
 	data := map[string]interface{}{
-		"FuncName": funcName,
-		"Step":     step,
-		"SubStep":  subStep,
-		"Desc":     desc,
+		"FuncName":  displayName(funcName),
+		"Style":     e.style,
+		"CFGImg":    p.cfgImg(step, subStep),
+		"LoopsImg":  p.loopsImg(),
+		"Desc":      desc,
+		"Narration": narrate(funcName, prim, step, subStep),
+		"Prim":      prim,
+		"Loops":     loops,
+		"HasLoops":  hasLoops,
+	}
+	if hasProfile {
+		data["ProfileImg"] = p.profileImg(step, subStep)
+	}
+	if r != nil {
+		data["ResidualImg"] = p.residualImg()
+		data["ResidualDesc"] = r.desc()
+		data["Regions"] = r.regionDescs()
+	}
+	// Generate syntax highlighted raw control flow graph and primitive.
+	dotSource, err := ioutil.ReadFile(p.stepDOT(step, subStep))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	dotCode, err := e.highlightRaw(dotLexer, string(dotSource))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	data["DOTCode"] = dotCode
+	if prim != nil {
+		primSource, err := json.MarshalIndent(prim, "", "\t")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		primCode, err := e.highlightRaw(lexers.Get("json"), string(primSource)+"\n")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		data["PrimCode"] = primCode
 	}
 	if err := e.cfaTmpl.Execute(htmlContent, data); err != nil {
 		return errors.WithStack(err)
 	}
-	htmlName := fmt.Sprintf("%s_step_%04d%s_cfa.html", funcName, step, subStep)
-	htmlPath := filepath.Join(e.outputDir, htmlName)
+	htmlPath := p.path(p.cfaPage(step, subStep))
 	dbg.Printf("creating file %q", htmlPath)
 	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
 		return errors.WithStack(err)
 	}
 	return nil
 }
+
+// outputResidual outputs the residual control flow graph of the given function,
+// which remains after merging all recovered control flow primitives,
+// highlighting its irreducible regions.
+//
+// - funcName is the function name of the analyzed function.
+//
+// - r is the residual control flow graph.
+func (e *explorer) outputResidual(funcName string, r *residual) error {
+	p := e.paths(funcName)
+	dotPath := p.residualDOT()
+	dbg.Printf("creating file %q", dotPath)
+	if err := ioutil.WriteFile(dotPath, []byte(r.dot()), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := outputImg(dotPath, p.path(p.residualImg())); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// highlightRaw returns the syntax highlighted HTML representation of the given
+// raw source (e.g. in DOT or JSON format), as tokenized by lexer.
+func (e *explorer) highlightRaw(lexer chroma.Lexer, source string) (template.HTML, error) {
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	// Get Chrome style.
+	style := styles.Get(e.style)
+	if style == nil {
+		style = styles.Fallback
+	}
+	// Get Chroma HTML formatter.
+	formatter := html.New(
+		html.TabWidth(3),
+		html.WithLineNumbers(),
+		html.WithClasses(),
+		html.LineNumbersInTable(),
+	)
+	iterator, err := lexer.Tokenise(nil, source)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	code := &bytes.Buffer{}
+	if err := formatter.Format(code, style, iterator); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return template.HTML(code.String()), nil
+}
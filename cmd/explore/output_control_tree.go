@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/llir/llvm/ir"
+	"github.com/mewmew/lnp/pkg/cfa/primitive"
+	"github.com/pkg/errors"
+)
+
+// parseControlTreeTemplate parses the control tree HTML template.
+func (e *explorer) parseControlTreeTemplate() error {
+	tmplName := "control_tree.tmpl"
+	tmplPath := filepath.Join(e.repoDir, "cmd/explore", tmplName)
+	ts, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	e.controlTreeTmpl = ts.Lookup(tmplName)
+	return nil
+}
+
+// controlHighlight specifies the lines to highlight when selecting a node of
+// the control tree, or a basic block of an execution trace.
+type controlHighlight struct {
+	// Line ranges (1-based: [start, end]) of the LLVM IR assembly.
+	LLVM [][2]int `json:"llvm"`
+	// Line ranges (1-based: [start, end]) of the original C source code.
+	C [][2]int `json:"c"`
+	// Line ranges (1-based: [start, end]) of the reconstructed Go source code
+	// of step 0; or nil if not located.
+	Go [][2]int `json:"go,omitempty"`
+}
+
+// blockHighlights returns the lines of each basic block of the given function
+// in the LLVM IR assembly and original C source code of step 0, indexed by
+// basic block name.
+//
+// - hasC specifies whether the original C source code is present.
+func (e *explorer) blockHighlights(f *ir.Func, hasC bool) (map[string]controlHighlight, error) {
+	l, err := printFunc(f)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if e.fullModule {
+		if start, ok := e.moduleLLVM().defs[f.Ident()]; ok {
+			l.shift(start - 1)
+		}
+	}
+	// Function with debug information, used to locate lines of the original C
+	// source code.
+	var cFunc *ir.Func
+	if hasC {
+		m := e.m
+		if e.dbg != nil {
+			m = e.dbg
+		}
+		if cFunc, err = findFunc(m, f.Name()); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	highlights := make(map[string]controlHighlight)
+	for _, block := range f.Blocks {
+		blockName := block.Name()
+		lines, err := l.blockLines(f, blockName)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		h := controlHighlight{LLVM: [][2]int{lines}}
+		if cFunc != nil {
+			if block, err := findBlock(cFunc, blockName); err == nil {
+				h.C = findBlockLines(block)
+			}
+		}
+		highlights[blockName] = h
+	}
+	return highlights, nil
+}
+
+// outputControlTree outputs the control tree of the given function, which
+// presents the recovered control flow primitives as a hierarchy of nested
+// primitives, and the original control flow graph overlayed with the regions of
+// the recovered primitives. Selecting a node of the control tree highlights its
+// basic blocks in the LLVM IR assembly and the original C source code of step
+// 0.
+//
+// - g is the control flow graph of the analyzed function.
+//
+// - pages specifies the intermediate step presented on each overview page.
+//
+// - prims is the list of recovered control flow primitives.
+//
+// - hasC specifies whether the original C source code is present.
+func (e *explorer) outputControlTree(g *cfg, pages []stepPage, prims []*primitive.Primitive, hasC bool) error {
+	f := g.f
+	funcName := f.Name()
+	roots := controlTree(f, prims)
+	// Output original control flow graph overlayed with regions.
+	if err := e.outputRegions(g, roots); err != nil {
+		return errors.WithStack(err)
+	}
+	// Locate lines of the basic blocks of each node.
+	blockHighlights, err := e.blockHighlights(f, hasC)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	p := e.paths(funcName)
+	var highlights []controlHighlight
+	for _, root := range roots {
+		root.walk(func(n *controlNode) {
+			if page := pageOf(pages, n.Step, "b"); n.Step > 0 && page > 0 {
+				n.Link = p.overviewPage(page)
+			}
+			var h controlHighlight
+			for _, blockName := range n.Blocks {
+				h.LLVM = append(h.LLVM, blockHighlights[blockName].LLVM...)
+				h.C = append(h.C, blockHighlights[blockName].C...)
+			}
+			highlights = append(highlights, h)
+		})
+	}
+	// Generate control tree HTML page.
+	htmlContent := &bytes.Buffer{}
+	data := map[string]interface{}{
+		"FuncName":   displayName(funcName),
+		"Roots":      roots,
+		"Complete":   len(roots) == 1,
+		"Highlights": highlights,
+		"RegionsImg": p.regionsImg(),
+		"LLVMPage":   p.llvmPage(0),
+		"FirstLink":  p.overviewPage(1),
+		"ExecLink":   p.execPage(),
+	}
+	if hasC {
+		data["CPage"] = p.cPage(0)
+	}
+	if err := e.controlTreeTmpl.Execute(htmlContent, data); err != nil {
+		return errors.WithStack(err)
+	}
+	htmlPath := p.path(p.controlTreePage())
+	dbg.Printf("creating file %q", htmlPath)
+	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parseDFSTemplate parses the depth-first search HTML template.
+func (e *explorer) parseDFSTemplate() error {
+	tmplName := "dfs.tmpl"
+	tmplPath := filepath.Join(e.repoDir, "cmd/explore", tmplName)
+	ts, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	e.dfsTmpl = ts.Lookup(tmplName)
+	return nil
+}
+
+// dfsNode is a node of the depth-first search spanning tree, as presented on
+// the depth-first search page.
+type dfsNode struct {
+	// Node name.
+	Name string
+	// Preorder, postorder and reverse postorder numbers; or 0 if unreachable.
+	Pre, Post, RPO int
+	// Parent node name in the DFS spanning tree; or empty if root or
+	// unreachable.
+	Parent string
+}
+
+// dfsEdge is a classified edge of the depth-first search, as presented on the
+// depth-first search page.
+type dfsEdge struct {
+	// Source and target node names.
+	From, To string
+	// Edge kind; one of "tree", "back", "forward" and "cross".
+	Kind string
+	// Note on the edge; e.g. whether a back edge forms a natural loop.
+	Note string
+}
+
+// outputDFS outputs the depth-first search spanning tree of the given function,
+// with preorder, postorder and reverse postorder numbers on nodes and every
+// edge classified as tree, back, forward or cross edge, and a step-by-step
+// animation of the depth-first search.
+//
+// - g is the control flow graph of the analyzed function.
+//
+// - dom is the dominator tree of g.
+func (e *explorer) outputDFS(g *cfg, dom *domTree) error {
+	t := depthFirst(g)
+	funcName := g.f.Name()
+	p := e.paths(funcName)
+	dotPath := p.dfsDOT()
+	dotContent := dfsDOT(g, t)
+	dbg.Printf("creating file %q", dotPath)
+	if err := ioutil.WriteFile(dotPath, []byte(dotContent), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	// Output image in SVG format, to animate nodes and edges of the inlined
+	// image.
+	svgPath := p.path(p.dfsImg())
+	if err := outputImg(dotPath, svgPath); err != nil {
+		return errors.WithStack(err)
+	}
+	svg, err := readSVG(svgPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var nodes []dfsNode
+	for n, name := range g.names {
+		node := dfsNode{Name: name, Pre: t.pre[n], Post: t.post[n], RPO: t.rpo[n]}
+		if parent := t.parent[n]; parent != -1 {
+			node.Parent = g.names[parent]
+		}
+		nodes = append(nodes, node)
+	}
+	var edges []dfsEdge
+	kinds := make(map[string]int)
+	for from, succs := range g.succs {
+		for _, to := range succs {
+			kind, ok := t.edgeKinds[[2]int{from, to}]
+			if !ok {
+				// Edge unreachable from the entry node.
+				continue
+			}
+			kinds[kind]++
+			edge := dfsEdge{From: g.names[from], To: g.names[to], Kind: kind}
+			if kind == edgeBack {
+				if dom.dominates(to, from) {
+					edge.Note = fmt.Sprintf("natural loop with header %%%s", g.names[to])
+				} else {
+					edge.Note = fmt.Sprintf("%%%s does not dominate %%%s; irreducible", g.names[to], g.names[from])
+				}
+			}
+			edges = append(edges, edge)
+		}
+	}
+	// Generate depth-first search HTML page.
+	htmlContent := &bytes.Buffer{}
+	data := map[string]interface{}{
+		"FuncName":        displayName(funcName),
+		"SVG":             svg,
+		"Nodes":           nodes,
+		"Edges":           edges,
+		"Kinds":           kinds,
+		"Events":          t.events,
+		"FirstLink":       p.overviewPage(1),
+		"ControlTreeLink": p.controlTreePage(),
+	}
+	if err := e.dfsTmpl.Execute(htmlContent, data); err != nil {
+		return errors.WithStack(err)
+	}
+	htmlPath := p.path(p.dfsPage())
+	dbg.Printf("creating file %q", htmlPath)
+	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// dfsEdgeAttrs maps from edge kind to the Graphviz DOT attributes of edges of
+// the given kind.
+var dfsEdgeAttrs = map[string]string{
+	edgeTree:    `penwidth=2`,
+	edgeBack:    `color=blue fontcolor=blue style=dashed`,
+	edgeForward: `color=darkgreen fontcolor=darkgreen`,
+	edgeCross:   `color=gray50 fontcolor=gray50 style=dotted`,
+}
+
+// dfsDOT returns a representation of the control flow graph in Graphviz DOT
+// format, with nodes labelled by preorder, postorder and reverse postorder
+// number, and edges labelled and styled by edge kind. Nodes and edges have the
+// IDs "dfs_node_N" and "dfs_edge_FROM_TO", used to animate the depth-first
+// search.
+func dfsDOT(g *cfg, t *dfsTree) string {
+	buf := &strings.Builder{}
+	buf.WriteString("digraph dfs {\n")
+	for n, name := range g.names {
+		fmt.Fprintf(buf, "\t%d [id=\"dfs_node_%d\"", n, n)
+		if t.pre[n] == 0 {
+			fmt.Fprintf(buf, " label=%s color=gray50 fontcolor=gray50 style=dashed]\n", dotQuote(name+"\nunreachable"))
+			continue
+		}
+		label := fmt.Sprintf("%s\npre %d, post %d, rpo %d", name, t.pre[n], t.post[n], t.rpo[n])
+		fmt.Fprintf(buf, " label=%s", dotQuote(label))
+		if n == 0 {
+			buf.WriteString(" peripheries=2")
+		}
+		buf.WriteString("]\n")
+	}
+	for from, succs := range g.succs {
+		for _, to := range succs {
+			fmt.Fprintf(buf, "\t%d -> %d [id=\"dfs_edge_%d_%d\"", from, to, from, to)
+			kind, ok := t.edgeKinds[[2]int{from, to}]
+			if !ok {
+				buf.WriteString(" color=gray50 style=dashed]\n")
+				continue
+			}
+			fmt.Fprintf(buf, " label=%s %s", dotQuote(kind), dfsEdgeAttrs[kind])
+			if kind == edgeTree {
+				// Only tree edges constrain the layout, to present the DFS
+				// spanning tree top-down.
+				buf.WriteString("]\n")
+				continue
+			}
+			buf.WriteString(" constraint=false]\n")
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parseDomTemplate parses the dominator tree HTML template.
+func (e *explorer) parseDomTemplate() error {
+	tmplName := "dom.tmpl"
+	tmplPath := filepath.Join(e.repoDir, "cmd/explore", tmplName)
+	ts, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	e.domTmpl = ts.Lookup(tmplName)
+	return nil
+}
+
+// outputDom outputs the dominator tree and post-dominator tree of the given
+// function, highlighting the nodes associated with the basic blocks of the
+// recovered control flow primitive.
+//
+// - g is the control flow graph of the analyzed function.
+//
+// - dom is the dominator tree of g.
+//
+// - postDom is the post-dominator tree of g.
+//
+// - blocks is the list of original basic block names of the recovered control
+//   flow primitive; or nil if not present.
+//
+// - step is the intermediate step of the control flow analysis.
+func (e *explorer) outputDom(g *cfg, dom, postDom *domTree, blocks []string, step int) error {
+	highlight := make(map[int]bool)
+	for _, n := range g.nodes(blocks) {
+		highlight[n] = true
+	}
+	p := e.paths(g.f.Name())
+	trees := []struct {
+		kind string
+		t    *domTree
+	}{
+		{kind: "dom", t: dom},
+		{kind: "post_dom", t: postDom},
+	}
+	for _, tree := range trees {
+		dotPath := p.domDOT(step, tree.kind)
+		dotContent := domTreeDOT(g, tree.t, tree.kind, highlight)
+		dbg.Printf("creating file %q", dotPath)
+		if err := ioutil.WriteFile(dotPath, []byte(dotContent), 0644); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := outputImg(dotPath, p.path(p.domImg(step, tree.kind))); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return e.outputDomHTML(g.f.Name(), step)
+}
+
+// outputDomHTML outputs the dominator tree and post-dominator tree of the given
+// function in HTML format.
+//
+// - funcName is the function name of the analyzed function.
+//
+// - step is the intermediate step of the control flow analysis.
+func (e *explorer) outputDomHTML(funcName string, step int) error {
+	// Generate dominator tree HTML page.
+	p := e.paths(funcName)
+	htmlContent := &bytes.Buffer{}
+	data := map[string]interface{}{
+		"FuncName":   displayName(funcName),
+		"DomImg":     p.domImg(step, "dom"),
+		"PostDomImg": p.domImg(step, "post_dom"),
+	}
+	if err := e.domTmpl.Execute(htmlContent, data); err != nil {
+		return errors.WithStack(err)
+	}
+	htmlPath := p.path(p.domPage(step))
+	dbg.Printf("creating file %q", htmlPath)
+	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// domTreeDOT returns a representation of the given dominator tree (or
+// post-dominator tree) in Graphviz DOT format, filling the highlighted nodes in
+// red.
+//
+// Node IDs of the DOT graph are node indices of g; the virtual exit node of
+// post-dominator trees has node index len(g.names).
+func domTreeDOT(g *cfg, t *domTree, graphName string, highlight map[int]bool) string {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "digraph %s {\n", graphName)
+	for n := range t.idom {
+		if n != t.root && t.idom[n] == -1 {
+			// Skip nodes unreachable from the root node.
+			continue
+		}
+		if n == len(g.names) {
+			fmt.Fprintf(buf, "\t%d [label=\"virtual exit\" shape=box style=dashed]\n", n)
+			continue
+		}
+		fmt.Fprintf(buf, "\t%d [label=%s", n, dotQuote(g.names[n]))
+		if highlight[n] {
+			buf.WriteString(" fillcolor=red style=filled")
+		}
+		buf.WriteString("]\n")
+	}
+	for n, idom := range t.idom {
+		if idom != -1 {
+			fmt.Fprintf(buf, "\t%d -> %d\n", idom, n)
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
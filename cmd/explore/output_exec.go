@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// parseExecTemplate parses the execution trace HTML template.
+func (e *explorer) parseExecTemplate() error {
+	tmplName := "exec.tmpl"
+	tmplPath := filepath.Join(e.repoDir, "cmd/explore", tmplName)
+	ts, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	e.execTmpl = ts.Lookup(tmplName)
+	return nil
+}
+
+// outputExec outputs a replay of the executed basic block trace of the given
+// function, which highlights the current basic block in the original control
+// flow graph, the LLVM IR assembly, the original C source code and the
+// reconstructed Go source code of step 0.
+//
+// - trace is the executed basic block trace of the function.
+func (e *explorer) outputExec(f *ir.Func, trace *execTrace) error {
+	funcName := f.Name()
+	p := e.paths(funcName)
+	// Output original control flow graph in SVG format, to highlight nodes and
+	// edges of the inlined image.
+	svgPath := p.path(p.execImg())
+	if err := outputImg(p.cfgDOT(), svgPath); err != nil {
+		return errors.WithStack(err)
+	}
+	svg, err := readSVG(svgPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Locate lines of each basic block.
+	cSource, _, err := e.parseC()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	hasC := len(cSource) > 0
+	highlights, err := e.blockHighlights(f, hasC)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// The Go source code is not present for function names not representable
+	// for ll2go2.
+	if checkLL2GoName(funcName) == nil {
+		goSource, err := e.decompGo(funcName, nil)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for blockName, lines := range goBlockLines(goSource, f) {
+			h := highlights[blockName]
+			h.Go = [][2]int{lines}
+			highlights[blockName] = h
+		}
+	}
+	// Generate execution trace HTML page.
+	htmlContent := &bytes.Buffer{}
+	data := map[string]interface{}{
+		"FuncName":        displayName(funcName),
+		"Call":            fmt.Sprintf("%s(%s)", displayName(funcName), strings.Join(trace.Args, ", ")),
+		"Result":          trace.Result,
+		"Err":             trace.Err,
+		"Trace":           trace.Blocks,
+		"Highlights":      highlights,
+		"SVG":             svg,
+		"LLVMPage":        p.llvmPage(0),
+		"GoPage":          p.goPage(0, ""),
+		"FirstLink":       p.overviewPage(1),
+		"ControlTreeLink": p.controlTreePage(),
+	}
+	if hasC {
+		data["CPage"] = p.cPage(0)
+	}
+	if err := e.execTmpl.Execute(htmlContent, data); err != nil {
+		return errors.WithStack(err)
+	}
+	htmlPath := p.path(p.execPage())
+	dbg.Printf("creating file %q", htmlPath)
+	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// outputExecPlaceholder outputs a placeholder of the execution trace page of
+// the given function, which describes how to record an execution trace. The
+// placeholder is replaced by the `explore exec` command.
+func (e *explorer) outputExecPlaceholder(f *ir.Func) error {
+	funcName := f.Name()
+	p := e.paths(funcName)
+	htmlContent := &bytes.Buffer{}
+	data := map[string]interface{}{
+		"FuncName":        displayName(funcName),
+		"Placeholder":     true,
+		"Command":         fmt.Sprintf("explore exec %s %s%s", e.llPath, funcName, strings.Repeat(" ARG", len(f.Params))),
+		"FirstLink":       p.overviewPage(1),
+		"ControlTreeLink": p.controlTreePage(),
+	}
+	if err := e.execTmpl.Execute(htmlContent, data); err != nil {
+		return errors.WithStack(err)
+	}
+	htmlPath := p.path(p.execPage())
+	dbg.Printf("creating file %q", htmlPath)
+	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// goLabel matches label statements of the reconstructed Go source code.
+var goLabel = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*):\s*$`)
+
+// goNonIdent matches characters of basic block names which are not valid in Go
+// identifiers.
+var goNonIdent = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// goBlockLines locates the lines (1-based: [start, end]) of each basic block
+// in the reconstructed Go source code of step 0, in which basic blocks are
+// delimited by labels. A label belongs to the basic block with the longest name
+// which either equals the label or is a suffix of it following an underscore
+// (e.g. "block_3" for basic block "3"); the lines before the first label belong
+// to the entry basic block.
+//
+// The block lines are located on a best-effort basis; labels which do not match
+// any basic block are considered part of the preceding basic block.
+func goBlockLines(goSource string, f *ir.Func) map[string][2]int {
+	lines := strings.Split(goSource, "\n")
+	// Locate the function body; between the function declaration and the last
+	// closing brace.
+	start, end := -1, -1
+	for i, line := range lines {
+		if start == -1 && strings.HasPrefix(line, "func ") {
+			start = i + 1
+		}
+		if line == "}" {
+			end = i
+		}
+	}
+	blockLines := make(map[string][2]int)
+	if start == -1 || end < start || len(f.Blocks) == 0 {
+		return blockLines
+	}
+	cur, curStart := f.Blocks[0].Name(), start
+	for i := start; i < end; i++ {
+		m := goLabel.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		blockName, ok := labelBlock(m[1], f)
+		if !ok {
+			continue
+		}
+		if i > curStart {
+			blockLines[cur] = [2]int{curStart + 1, i}
+		}
+		cur, curStart = blockName, i
+	}
+	if end > curStart {
+		blockLines[cur] = [2]int{curStart + 1, end}
+	}
+	return blockLines
+}
+
+// labelBlock returns the name of the basic block of the given Go label. The
+// boolean return value indicates success.
+func labelBlock(label string, f *ir.Func) (string, bool) {
+	var blockName, ident string
+	for _, block := range f.Blocks {
+		name := block.Name()
+		s := goNonIdent.ReplaceAllString(name, "_")
+		if (label == s || strings.HasSuffix(label, "_"+s)) && len(s) > len(ident) {
+			blockName, ident = name, s
+		}
+	}
+	return blockName, len(ident) > 0
+}
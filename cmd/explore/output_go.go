@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/alecthomas/chroma/formatters/html"
 	"github.com/alecthomas/chroma/lexers"
@@ -43,26 +44,35 @@ func (e *explorer) parseGoTemplate() error {
 // - subStep specifies whether the intermediate step is before or after merge,
 //   where "a" specifies before and "b" after (using lexicographic naming to
 //   have files be listed in the logical order).
-func (e *explorer) outputGo(funcName string, prims []*primitive.Primitive, step int, subStep string) error {
+//
+// - baseSource is the reconstructed Go source code to compare against in the
+//   diff view.
+//
+// - baseName is the name of the step of baseSource (e.g. "step 2a"); or empty
+//   if not present.
+func (e *explorer) outputGo(funcName string, prims []*primitive.Primitive, step int, subStep, baseSource, baseName string) (string, error) {
+	if err := checkLL2GoName(funcName); err != nil {
+		// Output placeholder in place of the Go source code, rather than
+		// failing the exploration of the module.
+		if step == 0 {
+			warn.Printf("skipping decompilation of function %q; %v", funcName, err)
+		}
+		if err := e.outputGoPlaceholder(funcName, err.Error(), step, subStep); err != nil {
+			return "", errors.WithStack(err)
+		}
+		return "", nil
+	}
 	// Decompile LLVM IR assembly into Go source code.
-	var stepPrims []*primitive.Primitive
-	switch subStep {
-	case "a":
-		// Before merge.
-		stepPrims = prims[:step-1]
-	case "b":
-		// After merge.
-		stepPrims = prims[:step]
-	default:
-		// nothing to do.
-	}
-	goSource, err := e.decompGo(funcName, stepPrims)
+	goSource, err := e.decompGo(funcName, stepPrims(prims, step, subStep))
 	if err != nil {
-		return errors.WithStack(err)
+		return "", errors.WithStack(err)
 	}
 	var lines [][2]int
 	// TODO: calculate lines to highlight.
-	return e.outputGoHTML(goSource, funcName, lines, step, subStep)
+	if err := e.outputGoHTML(goSource, funcName, lines, step, subStep, baseSource, baseName); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return goSource, nil
 }
 
 // outputGoHTML outputs the recovered Go source code in HTML format,
@@ -79,7 +89,13 @@ func (e *explorer) outputGo(funcName string, prims []*primitive.Primitive, step
 // - subStep specifies whether the intermediate step is before or after merge,
 //   where "a" specifies before and "b" after (using lexicographic naming to
 //   have files be listed in the logical order).
-func (e *explorer) outputGoHTML(goSource, funcName string, lines [][2]int, step int, subStep string) error {
+//
+// - baseSource is the reconstructed Go source code to compare against in the
+//   diff view.
+//
+// - baseName is the name of the step of baseSource (e.g. "step 2a"); or empty
+//   if not present.
+func (e *explorer) outputGoHTML(goSource, funcName string, lines [][2]int, step int, subStep, baseSource, baseName string) error {
 	// Get Chroma Go lexer.
 	lexer := lexers.Get("go")
 	if lexer == nil {
@@ -111,15 +127,53 @@ func (e *explorer) outputGoHTML(goSource, funcName string, lines [][2]int, step
 	// Generate Go HTML page.
 	htmlContent := &bytes.Buffer{}
 	data := map[string]interface{}{
-		"FuncName": funcName,
+		"FuncName": displayName(funcName),
 		"Style":    e.style,
 		"GoCode":   template.HTML(goCode.String()),
+		"BaseName": baseName,
+	}
+	if len(baseName) > 0 {
+		// Generate diff against base Go source code.
+		diff := lineDiff(baseSource, goSource)
+		data["Changed"] = hasChanges(diff)
+		data["Hunks"] = unifiedHunks(diff, 3)
+		data["Rows"] = sideBySide(diff)
+	}
+	if err := e.goTmpl.Execute(htmlContent, data); err != nil {
+		return errors.WithStack(err)
+	}
+	p := e.paths(funcName)
+	htmlPath := p.path(p.goPage(step, subStep))
+	dbg.Printf("creating file %q", htmlPath)
+	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// outputGoPlaceholder outputs a placeholder in place of the recovered Go source
+// code in HTML format.
+//
+// - funcName is the function name of the analyzed function.
+//
+// - msg explains why the Go source code is not present.
+//
+// - step is the intermediate step of the control flow analysis.
+//
+// - subStep specifies whether the intermediate step is before or after merge,
+//   where "a" specifies before and "b" after.
+func (e *explorer) outputGoPlaceholder(funcName, msg string, step int, subStep string) error {
+	htmlContent := &bytes.Buffer{}
+	data := map[string]interface{}{
+		"FuncName":    displayName(funcName),
+		"Style":       e.style,
+		"Placeholder": msg,
 	}
 	if err := e.goTmpl.Execute(htmlContent, data); err != nil {
 		return errors.WithStack(err)
 	}
-	htmlName := fmt.Sprintf("%s_step_%04d%s_go.html", funcName, step, subStep)
-	htmlPath := filepath.Join(e.outputDir, htmlName)
+	p := e.paths(funcName)
+	htmlPath := p.path(p.goPage(step, subStep))
 	dbg.Printf("creating file %q", htmlPath)
 	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
 		return errors.WithStack(err)
@@ -127,9 +181,22 @@ func (e *explorer) outputGoHTML(goSource, funcName string, lines [][2]int, step
 	return nil
 }
 
+// checkLL2GoName checks that the given function name is representable for
+// ll2go2, which locates the control flow primitives of a function in a JSON
+// file named after the function, and splits its -funcs flag on commas.
+func checkLL2GoName(funcName string) error {
+	if strings.ContainsAny(funcName, "/,"+string(filepath.Separator)) {
+		return errors.Errorf("function name %q not representable for ll2go2", funcName)
+	}
+	return nil
+}
+
 // decompGo decompiles the LLVM IR module into Go source code, based on the
 // given recovered control flow primitives.
 func (e *explorer) decompGo(funcName string, prims []*primitive.Primitive) (string, error) {
+	if err := checkLL2GoName(funcName); err != nil {
+		return "", errors.WithStack(err)
+	}
 	// Create temporary directory used for decompilation.
 	tmpDir, err := ioutil.TempDir("", "decomp-")
 	if err != nil {
@@ -147,6 +214,9 @@ func (e *explorer) decompGo(funcName string, prims []*primitive.Primitive) (stri
 	if err := os.MkdirAll(tmpDotDir, 0755); err != nil {
 		return "", errors.WithStack(err)
 	}
+	// Note, ll2go locates the control flow primitives of a function based on
+	// the function name, which is why the function name slug is not used
+	// within the temporary directory.
 	jsonName := fmt.Sprintf("%s.json", funcName)
 	jsonPath := filepath.Join(tmpDotDir, jsonName)
 	if err := jsonutil.WriteFile(jsonPath, prims); err != nil {
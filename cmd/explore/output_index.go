@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// parseIndexTemplate parses the index HTML template.
+func (e *explorer) parseIndexTemplate() error {
+	tmplName := "index.tmpl"
+	tmplPath := filepath.Join(e.repoDir, "cmd/explore", tmplName)
+	ts, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	e.indexTmpl = ts.Lookup(tmplName)
+	return nil
+}
+
+// indexFunc is a function listed on the index page.
+type indexFunc struct {
+	// Function name; demangled if C++ or Rust function.
+	Name string
+	// Symbol name of the function, if different from the function name; used
+	// as tooltip.
+	Symbol string
+	// Number of basic blocks; 0 if function declaration.
+	NBlocks int
+	// Link to the visualization of the function; or empty if not visualized.
+	Link string
+}
+
+// outputIndex outputs the index page of the visualization, which lists the
+// functions of the module and presents the call graph of the module.
+//
+// - visualized specifies the set of function names for which visualizations
+//   have been generated.
+func (e *explorer) outputIndex(visualized map[string]bool) error {
+	// Links to the visualization of visualized functions; indexed by function
+	// name.
+	links := make(map[string]string)
+	for funcName := range visualized {
+		links[funcName] = e.paths(funcName).overviewPage(1)
+	}
+	// Output call graph of module. The links of the call graph image are
+	// resolved relative to the image, as it is embedded in the index page.
+	mp := e.modulePaths()
+	imgLinks := make(map[string]string)
+	for funcName, link := range links {
+		imgLinks[funcName] = relLink(mp.callGraphImg(), link)
+	}
+	cg := newCallGraph(e.m)
+	dotPath := mp.callGraphDOT()
+	dbg.Printf("creating file %q", dotPath)
+	if err := ioutil.WriteFile(dotPath, []byte(callGraphDOT(cg, imgLinks)), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := outputImg(dotPath, mp.path(mp.callGraphImg())); err != nil {
+		return errors.WithStack(err)
+	}
+	// Generate index HTML page.
+	var funcs []indexFunc
+	for _, f := range e.m.Funcs {
+		funcName := f.Name()
+		fn := indexFunc{
+			Name:    displayName(funcName),
+			NBlocks: len(f.Blocks),
+			Link:    links[funcName],
+		}
+		if fn.Name != funcName {
+			fn.Symbol = funcName
+		}
+		funcs = append(funcs, fn)
+	}
+	htmlContent := &bytes.Buffer{}
+	data := map[string]interface{}{
+		"Base":         filepath.Base(e.base),
+		"Funcs":        funcs,
+		"CallGraphImg": mp.callGraphImg(),
+	}
+	if err := e.indexTmpl.Execute(htmlContent, data); err != nil {
+		return errors.WithStack(err)
+	}
+	htmlPath := filepath.Join(e.outputDir, "index.html")
+	dbg.Printf("creating file %q", htmlPath)
+	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// callGraphDOT returns a representation of the given call graph in Graphviz
+// DOT format. Nodes of visualized functions link to their visualization, and
+// function declarations are drawn as dashed external nodes.
+//
+// - links specifies the links to the visualization of visualized functions;
+//   indexed by function name.
+func callGraphDOT(cg *callGraph, links map[string]string) string {
+	buf := &strings.Builder{}
+	buf.WriteString("digraph callgraph {\n")
+	index := make(map[*ir.Func]int)
+	for i, f := range cg.funcs {
+		index[f] = i
+		funcName := f.Name()
+		fmt.Fprintf(buf, "\t%d [label=%s", i, dotQuote(displayName(funcName)))
+		link, visualized := links[funcName]
+		switch {
+		case len(f.Blocks) == 0:
+			// Function declaration.
+			buf.WriteString(` shape=box style=dashed tooltip="external function"`)
+		case visualized:
+			fmt.Fprintf(buf, ` URL=%s target="_top" tooltip="explore function"`, dotQuote(link))
+		default:
+			buf.WriteString(` color=gray fontcolor=gray`)
+		}
+		buf.WriteString("]\n")
+	}
+	for _, caller := range cg.funcs {
+		for _, callee := range cg.callees[caller] {
+			fmt.Fprintf(buf, "\t%d -> %d\n", index[caller], index[callee])
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/styles"
+	"github.com/pkg/errors"
+)
+
+// parseIntervalsTemplate parses the interval analysis HTML template.
+func (e *explorer) parseIntervalsTemplate() error {
+	tmplName := "intervals.tmpl"
+	tmplPath := filepath.Join(e.repoDir, "cmd/explore", tmplName)
+	ts, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	e.intervalsTmpl = ts.Lookup(tmplName)
+	return nil
+}
+
+// intervalInfo is an interval of a graph of the derived sequence, as presented
+// on the interval analysis page.
+type intervalInfo struct {
+	// Header node name.
+	Header string
+	// Node names of the interval, in order of addition; header node first.
+	Nodes []string
+	// Number of basic blocks of the original control flow graph in the
+	// interval.
+	NBlocks int
+}
+
+// intervalHighlight specifies the lines to highlight when selecting an interval
+// of the interval analysis page.
+type intervalHighlight struct {
+	// Lines of the header basic block of the interval.
+	Header controlHighlight `json:"header"`
+	// Lines of the remaining basic blocks of the interval.
+	Members controlHighlight `json:"members"`
+}
+
+// outputIntervals outputs the interval analysis of the given function, which
+// presents the derived sequence of graphs G1, ..., Gn as a sequence of pages,
+// one per graph, with the intervals of each graph drawn as clusters. Selecting
+// an interval highlights its header basic block and member basic blocks in the
+// LLVM IR assembly and the original C source code of step 0.
+//
+// - g is the control flow graph of the analyzed function.
+//
+// - hasC specifies whether the original C source code is present.
+func (e *explorer) outputIntervals(g *cfg, hasC bool) error {
+	funcName := g.f.Name()
+	p := e.paths(funcName)
+	seq := derivedSequence(g)
+	blockHighlights, err := e.blockHighlights(g.f, hasC)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var graphLinks []pageLink
+	for i := range seq {
+		graphLinks = append(graphLinks, pageLink{Page: i + 1, Link: p.intervalsPage(i + 1)})
+	}
+	for i, d := range seq {
+		n := i + 1
+		dotPath := p.intervalsDOT(n)
+		dotContent := intervalsDOT(d)
+		dbg.Printf("creating file %q", dotPath)
+		if err := ioutil.WriteFile(dotPath, []byte(dotContent), 0644); err != nil {
+			return errors.WithStack(err)
+		}
+		// Output image in SVG format, to highlight intervals of the inlined
+		// image.
+		svgPath := p.path(p.intervalsImg(n))
+		if err := outputImg(dotPath, svgPath); err != nil {
+			return errors.WithStack(err)
+		}
+		svg, err := readSVG(svgPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		var (
+			intervals  []intervalInfo
+			highlights []intervalHighlight
+		)
+		for _, interval := range d.intervals {
+			info := intervalInfo{Header: d.names[interval[0]]}
+			var h intervalHighlight
+			for _, node := range interval {
+				info.Nodes = append(info.Nodes, d.names[node])
+				for _, blockName := range d.blocks[node] {
+					info.NBlocks++
+					bh := blockHighlights[blockName]
+					if blockName == info.Header {
+						h.Header = bh
+						continue
+					}
+					h.Members.LLVM = append(h.Members.LLVM, bh.LLVM...)
+					h.Members.C = append(h.Members.C, bh.C...)
+				}
+			}
+			intervals = append(intervals, info)
+			highlights = append(highlights, h)
+		}
+		// Panes of the interval analysis page, in display order.
+		var panes []overviewPane
+		if hasC {
+			panes = append(panes, overviewPane{ID: "c", Title: "Original C source code", ShortTitle: "C", Link: p.cPage(0)})
+		}
+		panes = append(panes, overviewPane{ID: "llvm", Title: "LLVM IR assembly", ShortTitle: "LLVM", Link: p.llvmPage(0)})
+		// Generate interval analysis HTML page.
+		htmlContent := &bytes.Buffer{}
+		data := map[string]interface{}{
+			"FuncName":   displayName(funcName),
+			"Style":      e.style,
+			"Styles":     styles.Names(),
+			"Graph":      fmt.Sprintf("G%d", n),
+			"Graphs":     graphLinks,
+			"CurGraph":   n,
+			"Desc":       intervalsDesc(g, seq, i),
+			"SVG":        svg,
+			"Intervals":  intervals,
+			"Highlights": highlights,
+			"Panes":      panes,
+			"FirstLink":  p.overviewPage(1),
+		}
+		if n == len(seq) && len(d.names) > 1 {
+			data["Banner"] = fmt.Sprintf("The limit graph G%d has %d nodes, none of which is merged into the interval of another; the control flow graph is irreducible.", n, len(d.names))
+		}
+		if n > 1 {
+			data["PrevLink"] = p.intervalsPage(n - 1)
+		}
+		if n < len(seq) {
+			data["NextLink"] = p.intervalsPage(n + 1)
+		}
+		if err := e.intervalsTmpl.Execute(htmlContent, data); err != nil {
+			return errors.WithStack(err)
+		}
+		htmlPath := p.path(p.intervalsPage(n))
+		dbg.Printf("creating file %q", htmlPath)
+		if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// intervalsDesc returns a plain-language description of the given graph of the
+// derived sequence of graphs.
+//
+// - g is the control flow graph of the analyzed function.
+//
+// - seq is the derived sequence of graphs of g.
+//
+// - i is the index of the described graph in seq.
+func intervalsDesc(g *cfg, seq []*derivedGraph, i int) string {
+	d := seq[i]
+	var desc string
+	if i == 0 {
+		desc = fmt.Sprintf("G1 is the control flow graph of %d basic blocks", len(d.names))
+		if unreachable := len(g.names) - len(d.names); unreachable > 0 {
+			desc += fmt.Sprintf(", omitting %d basic blocks unreachable from the entry node", unreachable)
+		}
+		desc += "."
+	} else {
+		desc = fmt.Sprintf("G%d is derived from G%d by collapsing each interval into a single node, named after its header.", i+1, i)
+	}
+	desc += fmt.Sprintf(" Its %d %s partitioned into %d %s.", len(d.names), plural(len(d.names), "node is", "nodes are"), len(d.intervals), plural(len(d.intervals), "interval", "intervals"))
+	if i == len(seq)-1 {
+		desc += fmt.Sprintf(" G%d is the limit graph of the derived sequence", i+1)
+		if len(d.names) == 1 {
+			desc += "; a single node, so the control flow graph is reducible."
+		} else {
+			desc += "."
+		}
+	}
+	return desc
+}
+
+// plural returns singular if n is 1, and plural otherwise.
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+// intervalsDOT returns a representation of the given graph of the derived
+// sequence of graphs in Graphviz DOT format, with each interval drawn as a
+// cluster with the ID "interval_N", and header nodes drawn with a double
+// border.
+func intervalsDOT(d *derivedGraph) string {
+	buf := &strings.Builder{}
+	buf.WriteString("digraph intervals {\n")
+	for id, interval := range d.intervals {
+		fmt.Fprintf(buf, "\tsubgraph cluster_%d {\n", id)
+		label := fmt.Sprintf("I(%s)", d.names[interval[0]])
+		fmt.Fprintf(buf, "\t\tid=\"interval_%d\" label=%s style=rounded color=gray50\n", id, dotQuote(label))
+		for i, node := range interval {
+			label := d.names[node]
+			if nblocks := len(d.blocks[node]); nblocks > 1 {
+				label += fmt.Sprintf("\n%d blocks", nblocks)
+			}
+			tooltip := strings.Join(d.blocks[node], ", ")
+			fmt.Fprintf(buf, "\t\t%d [label=%s tooltip=%s", node, dotQuote(label), dotQuote(tooltip))
+			if i == 0 {
+				buf.WriteString(" peripheries=2")
+			}
+			buf.WriteString("]\n")
+		}
+		buf.WriteString("\t}\n")
+	}
+	for from, succs := range d.succs {
+		for _, to := range succs {
+			fmt.Fprintf(buf, "\t%d -> %d\n", from, to)
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"bytes"
-	"fmt"
 	"html/template"
 	"io/ioutil"
 	"path/filepath"
@@ -36,32 +35,93 @@ func (e *explorer) parseLLVMTemplate() error {
 //
 // - prim is the recovered control flow primitives; or nil if not present.
 //
+// - irrBlocks is the list of basic block names of irreducible regions to
+//   highlight; or nil if not present.
+//
+// - prof is the execution profile of the function, presented in the heat
+//   gutter; or nil if not present.
+//
 // - step is the intermediate step of the control flow analysis.
-func (e *explorer) outputLLVM(funcName string, prim *primitive.Primitive, step int) error {
-	// Locate lines to highlight of control flow primitive.
-	var lines [][2]int
+func (e *explorer) outputLLVM(funcName string, prim *primitive.Primitive, irrBlocks []string, prof *profile, step int) error {
 	f, err := findFunc(e.m, funcName)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	// Print LLVM IR assembly of function, recording line ranges.
+	l, err := printFunc(f)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Locate lines to highlight of control flow primitive.
+	var lines [][2]int
 	if prim != nil {
-		lines, err = findLLVMHighlight(f, prim)
+		lines, err = findLLVMHighlight(f, l, prim)
 		if err != nil {
 			return errors.WithStack(err)
 		}
 	}
-	return e.outputLLVMHTML(f, lines, step)
+	for _, blockName := range irrBlocks {
+		blockLines, err := l.blockLines(f, blockName)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		lines = append(lines, blockLines)
+	}
+	heat, err := profileHeat(f, l, prof)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return e.outputLLVMHTML(f, l, lines, heat, step)
 }
 
 // outputLLVMHTML outputs the LLVM IR assembly in HTML format, highlighting the
 // specified lines.
 //
+// When presenting the LLVM IR assembly of the whole module, the analyzed
+// function is scrolled into view and references to top-level entities link to
+// their definitions.
+//
 // - f is the function to visualize.
 //
-// - lines is the list of lines to highlight.
+// - l is the LLVM IR assembly of f, with recorded line ranges.
+//
+// - lines is the list of lines to highlight, relative to the LLVM IR assembly
+//   of the function.
+//
+// - heat is the heat gutter of the execution profile, relative to the LLVM IR
+//   assembly of the function; or nil if not present.
 //
 // - step is the intermediate step of the control flow analysis.
-func (e *explorer) outputLLVMHTML(f *ir.Func, lines [][2]int, step int) error {
+func (e *explorer) outputLLVMHTML(f *ir.Func, l *funcListing, lines [][2]int, heat []heatLines, step int) error {
+	llvmSource := l.source
+	// Line range (1-based: [start, end]) of the analyzed function within the
+	// presented LLVM IR assembly.
+	funcLines := [2]int{1, 1 + strings.Count(llvmSource, "\n")}
+	chains, err := defUseChains(f, l)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	anchors := l.anchors(f)
+	// Line number of the definition of each top-level entity; or nil if only
+	// the analyzed function is presented.
+	var refs map[string]int
+	if e.fullModule {
+		mod := e.moduleLLVM()
+		if start, ok := mod.defs[f.Ident()]; ok {
+			llvmSource = mod.source
+			shift := start - 1
+			funcLines = [2]int{funcLines[0] + shift, funcLines[1] + shift}
+			lines = shiftLines(lines, shift)
+			shiftHeat(heat, shift)
+			shiftDefUse(chains, shift)
+			for anchor, line := range anchors {
+				anchors[anchor] = line + shift
+			}
+			refs = mod.defs
+		} else {
+			warn.Printf("unable to locate function %q in LLVM IR assembly of module", f.Ident())
+		}
+	}
 	// Get Chroma LLVM IR lexer.
 	lexer := lexers.Get("llvm")
 	if lexer == nil {
@@ -82,7 +142,6 @@ func (e *explorer) outputLLVMHTML(f *ir.Func, lines [][2]int, step int) error {
 		html.HighlightLines(lines),
 	)
 	// Generate syntax highlighted LLVM IR assembly.
-	llvmSource := f.LLString()
 	iterator, err := lexer.Tokenise(nil, llvmSource)
 	if err != nil {
 		return errors.WithStack(err)
@@ -95,15 +154,20 @@ func (e *explorer) outputLLVMHTML(f *ir.Func, lines [][2]int, step int) error {
 	htmlContent := &bytes.Buffer{}
 	funcName := f.Name()
 	data := map[string]interface{}{
-		"FuncName": funcName,
-		"Style":    e.style,
-		"LLVMCode": template.HTML(llvmCode.String()),
+		"FuncName":  displayName(funcName),
+		"Style":     e.style,
+		"LLVMCode":  template.HTML(llvmCode.String()),
+		"DefUse":    chains,
+		"FuncLines": funcLines,
+		"Anchors":   anchors,
+		"Refs":      refs,
+		"Heat":      heat,
 	}
 	if err := e.llvmTmpl.Execute(htmlContent, data); err != nil {
 		return errors.WithStack(err)
 	}
-	htmlName := fmt.Sprintf("%s_step_%04d_llvm.html", funcName, step)
-	htmlPath := filepath.Join(e.outputDir, htmlName)
+	p := e.paths(funcName)
+	htmlPath := p.path(p.llvmPage(step))
 	dbg.Printf("creating file %q", htmlPath)
 	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
 		return errors.WithStack(err)
@@ -113,32 +177,17 @@ func (e *explorer) outputLLVMHTML(f *ir.Func, lines [][2]int, step int) error {
 
 // findLLVMHighlight returns the line ranges to highlight in the given function
 // associated with the basic blocks of the recovered control flow primitive.
-func findLLVMHighlight(f *ir.Func, prim *primitive.Primitive) ([][2]int, error) {
+//
+// - l is the LLVM IR assembly of f, with recorded line ranges.
+func findLLVMHighlight(f *ir.Func, l *funcListing, prim *primitive.Primitive) ([][2]int, error) {
 	// Line number ranges to highlight (1-based line numbers, inclusive).
 	var lineRanges [][2]int
 	for _, blockName := range prim.Nodes {
-		block, err := findBlock(f, blockName)
+		lineRange, err := l.blockLines(f, blockName)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
-		lineRange := findBlockLineRange(f, block)
 		lineRanges = append(lineRanges, lineRange)
 	}
 	return lineRanges, nil
 }
-
-// findBlockLineRange returns the line range (1-based: [start, end]) of the
-// basic block in the given function.
-func findBlockLineRange(f *ir.Func, block *ir.Block) [2]int {
-	funcStr := f.LLString()
-	blockStr := block.LLString()
-	pos := strings.Index(funcStr, blockStr)
-	if pos == -1 {
-		panic(fmt.Errorf("unable to locate contents of basic block %s in contents of function %s", block.Ident(), f.Ident()))
-	}
-	before := funcStr[:pos]
-	start := 1 + strings.Count(before, "\n")
-	n := strings.Count(blockStr, "\n")
-	end := start + n
-	return [2]int{start, end}
-}
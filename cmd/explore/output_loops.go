@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/pkg/errors"
+)
+
+// outputLoops outputs the control flow graph of the given function, overlayed
+// with its loop nesting forest. Back edges are styled differently and the body
+// of each loop is boxed.
+//
+// - g is the control flow graph of the analyzed function.
+//
+// - loops is the list of natural loops of g.
+func (e *explorer) outputLoops(g *cfg, loops []*loop) error {
+	p := e.paths(g.f.Name())
+	dotPath := p.loopsDOT()
+	dotContent := loopsDOT(g, loops)
+	dbg.Printf("creating file %q", dotPath)
+	if err := ioutil.WriteFile(dotPath, []byte(dotContent), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := outputImg(dotPath, p.path(p.loopsImg())); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// loopsDOT returns a representation of the control flow graph in Graphviz DOT
+// format, with the body of each loop placed in a (nested) cluster and back
+// edges drawn as dashed blue edges.
+func loopsDOT(g *cfg, loops []*loop) string {
+	buf := &strings.Builder{}
+	buf.WriteString("digraph loops {\n")
+	// Innermost loop of each node; or nil if not part of any loop.
+	innermost := make([]*loop, len(g.names))
+	for _, l := range loops {
+		for _, n := range l.body {
+			if innermost[n] == nil || len(l.body) < len(innermost[n].body) {
+				innermost[n] = l
+			}
+		}
+	}
+	// Output nodes not part of any loop.
+	for n, name := range g.names {
+		if innermost[n] == nil {
+			fmt.Fprintf(buf, "\t%d [label=%s]\n", n, dotQuote(name))
+		}
+	}
+	// Output loop clusters.
+	var writeLoop func(l *loop, indent string)
+	writeLoop = func(l *loop, indent string) {
+		fmt.Fprintf(buf, "%ssubgraph cluster_loop_%d {\n", indent, l.header)
+		fmt.Fprintf(buf, "%s\tlabel=%s\n", indent, dotQuote("loop "+g.names[l.header]))
+		fmt.Fprintf(buf, "%s\tstyle=rounded\n", indent)
+		fmt.Fprintf(buf, "%s\tcolor=blue\n", indent)
+		for _, n := range l.body {
+			if innermost[n] != l {
+				continue
+			}
+			fmt.Fprintf(buf, "%s\t%d [label=%s", indent, n, dotQuote(g.names[n]))
+			if n == l.header {
+				buf.WriteString(" peripheries=2")
+			}
+			buf.WriteString("]\n")
+		}
+		for _, child := range l.children {
+			writeLoop(child, indent+"\t")
+		}
+		fmt.Fprintf(buf, "%s}\n", indent)
+	}
+	for _, l := range loops {
+		if l.parent == nil {
+			writeLoop(l, "\t")
+		}
+	}
+	// Output edges.
+	isBackEdge := make(map[[2]int]bool)
+	for _, l := range loops {
+		for _, latch := range l.latches {
+			isBackEdge[[2]int{latch, l.header}] = true
+		}
+	}
+	for from, succs := range g.succs {
+		for _, to := range succs {
+			fmt.Fprintf(buf, "\t%d -> %d", from, to)
+			if isBackEdge[[2]int{from, to}] {
+				buf.WriteString(` [color=blue fontcolor=blue label="back edge" penwidth=2 style=dashed]`)
+			} else if color, ok := edgeColor(g.f.Blocks[from], g.f.Blocks[to]); ok {
+				fmt.Fprintf(buf, " [color=%s]", color)
+			}
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// edgeColor returns the color of the edge from the given basic block to the
+// given target, using the same colors as ll2dot for true (darkgreen) and false
+// (red) branches of conditional branch terminators. The boolean return value
+// indicates whether the edge has a color.
+func edgeColor(from, to *ir.Block) (string, bool) {
+	term, ok := from.Term.(*ir.TermCondBr)
+	if !ok || term.TargetTrue == term.TargetFalse {
+		return "", false
+	}
+	switch to {
+	case term.TargetTrue:
+		return "darkgreen", true
+	case term.TargetFalse:
+		return "red", true
+	}
+	return "", false
+}
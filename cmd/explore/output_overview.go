@@ -2,12 +2,15 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 
 	"github.com/alecthomas/chroma/styles"
+	"github.com/mewmew/lnp/pkg/cfa/primitive"
 	"github.com/pkg/errors"
 )
 
@@ -28,40 +31,197 @@ func (e *explorer) parseOverviewTemplate() error {
 //
 // - funcName is the function name of the analyzed function.
 //
+// - pages specifies the intermediate step presented on each overview page.
+//
 // - page is the page number of the visualization.
 //
-// - npages is the total number of pages.
+// - prims is the list of recovered control flow primitives.
+//
+// - banner is an explanatory banner displayed at the top of the page; or empty
+//   if not present.
+//
+// - hasC specifies whether the original C source code is present, in which
+//   case the C pane is displayed.
 //
-// - subStep specifies whether the intermediate step is before or after merge,
-//   where "a" specifies before and "b" after (using lexicographic naming to
-//   have files be listed in the logical order).
-func (e *explorer) outputOverview(funcName string, page, npages, step int, subStep string) error {
+// - cSearch describes how the original C source file was searched for, as
+//   displayed in place of the C pane when not present.
+func (e *explorer) outputOverview(funcName string, pages []stepPage, page int, prims []*primitive.Primitive, banner string, hasC bool, cSearch []string) error {
 	// Generate Overview HTML page.
+	p := e.paths(funcName)
 	htmlContent := &bytes.Buffer{}
-	var pages []int
-	for i := 1; i <= npages; i++ {
-		pages = append(pages, i)
+	step, subStep := pages[page-1].step, pages[page-1].subStep
+	var prim *primitive.Primitive
+	if step > 0 {
+		prim = prims[step-1]
+	}
+	npages := len(pages)
+	// Links to a window of pages around the current page; gaps in the window
+	// are represented by page 0.
+	var window []pageLink
+	for _, i := range pageWindow(page, npages, 2) {
+		link := pageLink{Page: i}
+		if i != 0 {
+			link.Link = p.overviewPage(i)
+		}
+		window = append(window, link)
 	}
+	// Panes of the overview page, in display order.
+	var panes []overviewPane
+	if hasC {
+		panes = append(panes, overviewPane{ID: "c", Title: "Original C source code", ShortTitle: "C", Link: p.cPage(step)})
+	}
+	panes = append(panes,
+		overviewPane{ID: "llvm", Title: "LLVM IR assembly", ShortTitle: "LLVM", Link: p.llvmPage(step)},
+		overviewPane{ID: "cfa", Title: "Control flow analysis", ShortTitle: "CFA", Link: p.cfaPage(step, subStep)},
+		overviewPane{ID: "go", Title: "Reconstructed Go source code", ShortTitle: "Go", Link: p.goPage(step, subStep)},
+		overviewPane{ID: "dom", Title: "Dominator and post-dominator trees", ShortTitle: "Dominators", Link: p.domPage(step), Wide: true},
+	)
 	data := map[string]interface{}{
-		"FuncName": funcName,
-		"Style":    e.style,
-		"Styles":   styles.Names(),
-		"Pages":    pages,
-		"PrevPage": page - 1,
-		"CurPage":  page,
-		"NextPage": page + 1,
-		"NPages":   npages,
-		"Step":     step,
-		"SubStep":  subStep,
+		"FuncName":        displayName(funcName),
+		"Style":           e.style,
+		"Styles":          styles.Names(),
+		"Pages":           window,
+		"CurPage":         page,
+		"FirstLink":       p.overviewPage(1),
+		"LastLink":        p.overviewPage(npages),
+		"StepsJS":         p.stepsJS(),
+		"TranscriptLink":  p.transcriptTxt(),
+		"Narration":       narrate(funcName, prim, step, subStep),
+		"ControlTreeLink": p.controlTreePage(),
+		"DFSLink":         p.dfsPage(),
+		"IntervalsLink":   p.intervalsPage(1),
+		"ExecLink":        p.execPage(),
+		"Panes":           panes,
+		"Banner":          banner,
+	}
+	if !hasC {
+		data["CSearch"] = cSearch
+	}
+	if page > 1 {
+		data["PrevLink"] = p.overviewPage(page - 1)
+	}
+	if page < npages {
+		data["NextLink"] = p.overviewPage(page + 1)
 	}
 	if err := e.overviewTmpl.Execute(htmlContent, data); err != nil {
 		return errors.WithStack(err)
 	}
-	htmlName := fmt.Sprintf("%s_%04d.html", funcName, page)
-	htmlPath := filepath.Join(e.outputDir, htmlName)
+	htmlPath := p.path(p.overviewPage(page))
 	dbg.Printf("creating file %q", htmlPath)
 	if err := ioutil.WriteFile(htmlPath, htmlContent.Bytes(), 0644); err != nil {
 		return errors.WithStack(err)
 	}
 	return nil
 }
+
+// pageWindow returns the page numbers to link in the pagination bar; the first
+// and last pages, and the pages within radius of the current page. Gaps between
+// the linked pages are represented by page 0, except for gaps of a single page,
+// which are linked instead.
+func pageWindow(page, npages, radius int) []int {
+	var window []int
+	for i := 1; i <= npages; i++ {
+		switch {
+		case i == 1, i == npages, i >= page-radius && i <= page+radius:
+			window = append(window, i)
+		case i == 2 && page-radius == 3, i == npages-1 && page+radius == npages-2:
+			window = append(window, i)
+		case window[len(window)-1] != 0:
+			window = append(window, 0)
+		}
+	}
+	return window
+}
+
+// pageLink is a link to an overview page.
+type pageLink struct {
+	// Page number; or 0 if gap between linked pages.
+	Page int
+	// Link to the overview page.
+	Link string
+}
+
+// stepLink is a link to the overview page of an intermediate step, as used by
+// the timeline and step navigation of the overview page.
+type stepLink struct {
+	// Page number.
+	Page int `json:"page"`
+	// Intermediate step and substep; e.g. "2a".
+	Step string `json:"step"`
+	// Primitive name of the step; or empty on step 0.
+	Prim string `json:"prim"`
+	// Description of the step; e.g. "step 2a: if (before merge)".
+	Desc string `json:"desc"`
+	// Plain-language narration of the step.
+	Narration string `json:"narration"`
+	// Link to the overview page.
+	Link string `json:"link"`
+	// Control flow graph thumbnail of the step.
+	Img string `json:"img"`
+}
+
+// outputSteps outputs the links to the overview page of each intermediate step
+// of the given function, as a JavaScript file shared by the overview pages of
+// the function.
+//
+// - funcName is the function name of the analyzed function.
+//
+// - pages specifies the intermediate step presented on each overview page.
+//
+// - prims is the list of recovered control flow primitives.
+func (e *explorer) outputSteps(funcName string, pages []stepPage, prims []*primitive.Primitive) error {
+	p := e.paths(funcName)
+	var links []stepLink
+	for i, sp := range pages {
+		link := stepLink{
+			Page: i + 1,
+			Step: fmt.Sprintf("%d%s", sp.step, sp.subStep),
+			Link: p.overviewPage(i + 1),
+			Img:  p.cfgImg(sp.step, sp.subStep),
+		}
+		var prim *primitive.Primitive
+		if sp.step > 0 {
+			prim = prims[sp.step-1]
+		}
+		link.Narration = narrate(funcName, prim, sp.step, sp.subStep)
+		switch sp.subStep {
+		case "a":
+			link.Prim = prims[sp.step-1].Prim
+			link.Desc = fmt.Sprintf("step %s: %s (before merge)", link.Step, link.Prim)
+		case "b":
+			link.Prim = prims[sp.step-1].Prim
+			link.Desc = fmt.Sprintf("step %s: %s (after merge)", link.Step, link.Prim)
+		default:
+			link.Desc = "step 0: original control flow graph"
+		}
+		links = append(links, link)
+	}
+	buf, err := json.Marshal(links)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	jsPath := p.path(p.stepsJS())
+	if err := os.MkdirAll(filepath.Dir(jsPath), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	dbg.Printf("creating file %q", jsPath)
+	jsContent := fmt.Sprintf("var steps = %s;\n", buf)
+	if err := ioutil.WriteFile(jsPath, []byte(jsContent), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// overviewPane is a pane of the overview page.
+type overviewPane struct {
+	// Pane ID; e.g. "llvm".
+	ID string
+	// Pane title.
+	Title string
+	// Short pane title, as used in tabs.
+	ShortTitle string
+	// Link to the page displayed in the pane.
+	Link string
+	// Specifies whether the pane spans the full width of multi-column layouts.
+	Wide bool
+}
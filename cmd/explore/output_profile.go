@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/llir/llvm/ir"
+	"github.com/mewmew/lnp/pkg/cfa/primitive"
+	"github.com/pkg/errors"
+)
+
+// outputProfile outputs the control flow graph of the given intermediate step,
+// with block heat colors and edge thickness given by the execution profile.
+//
+// - g is the control flow graph of the analyzed function.
+//
+// - prof is the execution profile of the function.
+//
+// - prims is the list of recovered control flow primitives.
+//
+// - step is the intermediate step of the control flow analysis.
+//
+// - subStep specifies whether the intermediate step is before or after merge,
+//   where "a" specifies before and "b" after.
+func (e *explorer) outputProfile(g *cfg, prof *profile, prims []*primitive.Primitive, step int, subStep string) error {
+	p := e.paths(g.f.Name())
+	dotPath := p.profileDOT(step, subStep)
+	merged := mergedBlocks(g.f, stepPrims(prims, step, subStep))
+	dotContent := profileDOT(g, prof, merged)
+	dbg.Printf("creating file %q", dotPath)
+	if err := ioutil.WriteFile(dotPath, []byte(dotContent), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := outputImg(dotPath, p.path(p.profileImg(step, subStep))); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// heatLines specifies the heat color of a line range in the heat gutter of the
+// LLVM IR assembly.
+type heatLines struct {
+	// Line range (1-based: [start, end]).
+	Lines [2]int `json:"lines"`
+	// Heat color.
+	Color string `json:"color"`
+	// Execution frequency of the line range.
+	Title string `json:"title"`
+}
+
+// profileHeat returns the heat of the lines of each basic block of the given
+// function in the LLVM IR assembly, as given by the execution profile.
+//
+// - l is the LLVM IR assembly of f, with recorded line ranges.
+//
+// - prof is the execution profile of the function; or nil if not present.
+func profileHeat(f *ir.Func, l *funcListing, prof *profile) ([]heatLines, error) {
+	if prof == nil {
+		return nil, nil
+	}
+	max := prof.maxFreq()
+	var heat []heatLines
+	for n, block := range f.Blocks {
+		lines, err := l.blockLines(f, block.Name())
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		freq := prof.freqs[n]
+		title := fmt.Sprintf("executed %s times", prof.formatFreq(freq))
+		if !prof.measured {
+			title = fmt.Sprintf("executed an estimated %s per function call", prof.formatFreq(freq))
+		}
+		h := heatLines{
+			Lines: lines,
+			Color: heatColor(freq, max),
+			Title: title,
+		}
+		heat = append(heat, h)
+	}
+	return heat, nil
+}
+
+// shiftHeat shifts the line numbers of the given heat gutter by the specified
+// number of lines.
+func shiftHeat(heat []heatLines, shift int) {
+	for i := range heat {
+		heat[i].Lines = [2]int{heat[i].Lines[0] + shift, heat[i].Lines[1] + shift}
+	}
+}
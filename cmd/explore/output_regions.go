@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// outputRegions outputs the original control flow graph of the given function,
+// overlayed with the regions of the recovered control flow primitives.
+//
+// - g is the control flow graph of the analyzed function.
+//
+// - roots is the list of root nodes of the control tree of the function.
+func (e *explorer) outputRegions(g *cfg, roots []*controlNode) error {
+	p := e.paths(g.f.Name())
+	dotPath := p.regionsDOT()
+	dotContent := regionsDOT(g, roots)
+	dbg.Printf("creating file %q", dotPath)
+	if err := ioutil.WriteFile(dotPath, []byte(dotContent), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := outputImg(dotPath, p.path(p.regionsImg())); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// regionColors maps from primitive name to the border and fill colors of its
+// regions.
+var regionColors = map[string][2]string{
+	"seq":       {"#959da5", "#f6f8fa"},
+	"if":        {"#0366d6", "#f1f8ff"},
+	"if_else":   {"#28a745", "#f0fff4"},
+	"if_return": {"#dbab09", "#fffdef"},
+	"pre_loop":  {"#d73a49", "#ffeef0"},
+	"post_loop": {"#6f42c1", "#f5f0ff"},
+	"switch":    {"#e36209", "#fff8f2"},
+}
+
+// regionsDOT returns a representation of the original control flow graph in
+// Graphviz DOT format, with the original basic blocks of each recovered control
+// flow primitive placed in a (nested) cluster, colored by primitive.
+//
+// - roots is the list of root nodes of the control tree of the function.
+func regionsDOT(g *cfg, roots []*controlNode) string {
+	buf := &strings.Builder{}
+	buf.WriteString("digraph regions {\n")
+	var writeNode func(n *controlNode, indent string)
+	writeNode = func(n *controlNode, indent string) {
+		if len(n.Children) == 0 {
+			// Basic block.
+			if i, ok := g.index[n.Name]; ok {
+				fmt.Fprintf(buf, "%s%d [label=%s", indent, i, dotQuote(n.Name))
+				if i == 0 {
+					buf.WriteString(" peripheries=2")
+				}
+				buf.WriteString("]\n")
+			}
+			return
+		}
+		colors, ok := regionColors[n.Prim]
+		if !ok {
+			colors = [2]string{"#586069", "#fafbfc"}
+		}
+		fmt.Fprintf(buf, "%ssubgraph cluster_region_%d {\n", indent, n.ID)
+		fmt.Fprintf(buf, "%s\tlabel=%s\n", indent, dotQuote(fmt.Sprintf("%s (step %d)", n.Prim, n.Step)))
+		fmt.Fprintf(buf, "%s\tstyle=\"rounded,filled\"\n", indent)
+		fmt.Fprintf(buf, "%s\tcolor=%s\n", indent, dotQuote(colors[0]))
+		fmt.Fprintf(buf, "%s\tfillcolor=%s\n", indent, dotQuote(colors[1]))
+		fmt.Fprintf(buf, "%s\tfontcolor=%s\n", indent, dotQuote(colors[0]))
+		for _, child := range n.Children {
+			writeNode(child, indent+"\t")
+		}
+		fmt.Fprintf(buf, "%s}\n", indent)
+	}
+	for _, root := range roots {
+		writeNode(root, "\t")
+	}
+	for from, succs := range g.succs {
+		for _, to := range succs {
+			fmt.Fprintf(buf, "\t%d -> %d", from, to)
+			if label, ok := edgeLabel(g.f.Blocks[from], g.f.Blocks[to]); ok {
+				fmt.Fprintf(buf, " [label=%s]", dotQuote(label))
+			}
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
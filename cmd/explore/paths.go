@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// funcPaths maps a function to the paths of its output files. Every output
+// stage locates its output files through funcPaths, and output file names are
+// derived from the function name slug, so that any valid LLVM IR function name
+// produces valid and unique file names.
+//
+// Page and image names are relative to the explore output directory, and are
+// used both as links between pages and to locate the output files. Graph and
+// JSON paths are located in a subdirectory of the control flow graph directory
+// dedicated to the function ("funcs/SLUG/"), so that the file names of one
+// function never collide with those of another.
+type funcPaths struct {
+	// Function name slug.
+	slug string
+	// Explore output directory.
+	outputDir string
+	// Control flow graph directory.
+	dotDir string
+}
+
+// paths returns the output file paths of the given function.
+func (e *explorer) paths(funcName string) *funcPaths {
+	return &funcPaths{
+		slug:      e.funcSlug(funcName),
+		outputDir: e.outputDir,
+		dotDir:    e.dotDir,
+	}
+}
+
+// path returns the path of the given page or image name, relative to the
+// current working directory.
+func (p *funcPaths) path(name string) string {
+	return filepath.Join(p.outputDir, filepath.FromSlash(name))
+}
+
+// modulePaths maps the module to the paths of its output files, which are not
+// specific to any one function.
+//
+// Module images are located in a dedicated subdirectory of the image directory
+// ("img/module/"), and module graphs in the root of the control flow graph
+// directory. As function slugs never contain path separators, and function
+// graphs are located in "funcs/SLUG/", the module file names never collide
+// with those of a function.
+type modulePaths struct {
+	// Explore output directory.
+	outputDir string
+	// Control flow graph directory.
+	dotDir string
+}
+
+// modulePaths returns the output file paths of the module.
+func (e *explorer) modulePaths() *modulePaths {
+	return &modulePaths{
+		outputDir: e.outputDir,
+		dotDir:    e.dotDir,
+	}
+}
+
+// path returns the path of the given page or image name, relative to the
+// current working directory.
+func (p *modulePaths) path(name string) string {
+	return filepath.Join(p.outputDir, filepath.FromSlash(name))
+}
+
+// callGraphImg returns the name of the call graph image in SVG format, which is
+// embedded in the index page.
+func (p *modulePaths) callGraphImg() string {
+	return "img/module/callgraph.svg"
+}
+
+// callGraphDOT returns the path of the call graph in DOT format.
+func (p *modulePaths) callGraphDOT() string {
+	return filepath.Join(p.dotDir, "callgraph.dot")
+}
+
+// relLink returns the given page name as a link relative to the given page or
+// image name (e.g. an embedded image), which resolves links relative to its own
+// directory.
+func relLink(from, page string) string {
+	dir := path.Dir(from)
+	if dir == "." {
+		return page
+	}
+	return strings.Repeat("../", strings.Count(dir, "/")+1) + page
+}
+
+// --- [ HTML pages ] ----------------------------------------------------------
+
+// overviewPage returns the name of the given overview page.
+func (p *funcPaths) overviewPage(page int) string {
+	return fmt.Sprintf("%s_%04d.html", p.slug, page)
+}
+
+// cPage returns the name of the C source code page of the given step.
+func (p *funcPaths) cPage(step int) string {
+	return fmt.Sprintf("%s_step_%04d_c.html", p.slug, step)
+}
+
+// llvmPage returns the name of the LLVM IR assembly page of the given step.
+func (p *funcPaths) llvmPage(step int) string {
+	return fmt.Sprintf("%s_step_%04d_llvm.html", p.slug, step)
+}
+
+// cfaPage returns the name of the control flow analysis page of the given step
+// and substep.
+func (p *funcPaths) cfaPage(step int, subStep string) string {
+	return fmt.Sprintf("%s_step_%04d%s_cfa.html", p.slug, step, subStep)
+}
+
+// goPage returns the name of the Go source code page of the given step and
+// substep.
+func (p *funcPaths) goPage(step int, subStep string) string {
+	return fmt.Sprintf("%s_step_%04d%s_go.html", p.slug, step, subStep)
+}
+
+// domPage returns the name of the dominator tree page of the given step.
+func (p *funcPaths) domPage(step int) string {
+	return fmt.Sprintf("%s_step_%04d_dom.html", p.slug, step)
+}
+
+// stepsJS returns the name of the JavaScript file with links to the overview
+// page of each intermediate step.
+func (p *funcPaths) stepsJS() string {
+	return fmt.Sprintf("data/%s_steps.js", p.slug)
+}
+
+// transcriptTxt returns the name of the plain-text transcript of the control
+// flow analysis.
+func (p *funcPaths) transcriptTxt() string {
+	return fmt.Sprintf("%s_transcript.txt", p.slug)
+}
+
+// controlTreePage returns the name of the control tree page.
+func (p *funcPaths) controlTreePage() string {
+	return fmt.Sprintf("%s_control_tree.html", p.slug)
+}
+
+// dfsPage returns the name of the depth-first search page.
+func (p *funcPaths) dfsPage() string {
+	return fmt.Sprintf("%s_dfs.html", p.slug)
+}
+
+// intervalsPage returns the name of the interval analysis page of the given
+// graph (1-based) of the derived sequence of graphs. The graph number is
+// prefixed by "g", as a name ending with "_NNNN.html" may collide with the
+// overview page of another function.
+func (p *funcPaths) intervalsPage(n int) string {
+	return fmt.Sprintf("%s_intervals_g%d.html", p.slug, n)
+}
+
+// execPage returns the name of the page replaying the executed basic block
+// trace of the function.
+func (p *funcPaths) execPage() string {
+	return fmt.Sprintf("%s_exec.html", p.slug)
+}
+
+// comparePage returns the name of the page comparing two explorations of the
+// function.
+func (p *funcPaths) comparePage() string {
+	return fmt.Sprintf("%s_compare.html", p.slug)
+}
+
+// optPage returns the name of the page comparing explorations of the function
+// compiled using different optimization pipelines.
+func (p *funcPaths) optPage() string {
+	return fmt.Sprintf("%s_opt.html", p.slug)
+}
+
+// --- [ Images ] --------------------------------------------------------------
+
+// cfgImg returns the name of the control flow graph image of the given step and
+// substep.
+func (p *funcPaths) cfgImg(step int, subStep string) string {
+	return fmt.Sprintf("img/%s_step_%04d%s.png", p.slug, step, subStep)
+}
+
+// residualImg returns the name of the residual control flow graph image.
+func (p *funcPaths) residualImg() string {
+	return fmt.Sprintf("img/%s_residual.png", p.slug)
+}
+
+// loopsImg returns the name of the loop nesting forest image.
+func (p *funcPaths) loopsImg() string {
+	return fmt.Sprintf("img/%s_loops.png", p.slug)
+}
+
+// regionsImg returns the name of the image of the original control flow graph
+// overlayed with the regions of the recovered control flow primitives.
+func (p *funcPaths) regionsImg() string {
+	return fmt.Sprintf("img/%s_regions.png", p.slug)
+}
+
+// dfsImg returns the name of the depth-first search spanning tree image in SVG
+// format, which is inlined in the depth-first search page.
+func (p *funcPaths) dfsImg() string {
+	return fmt.Sprintf("img/%s_dfs.svg", p.slug)
+}
+
+// intervalsImg returns the name of the image in SVG format of the given graph
+// (1-based) of the derived sequence of graphs, which is inlined in the interval
+// analysis page.
+func (p *funcPaths) intervalsImg(n int) string {
+	return fmt.Sprintf("img/%s_intervals_g%d.svg", p.slug, n)
+}
+
+// execImg returns the name of the original control flow graph image in SVG
+// format, which is inlined in the execution trace page.
+func (p *funcPaths) execImg() string {
+	return fmt.Sprintf("img/%s_exec.svg", p.slug)
+}
+
+// profileImg returns the name of the control flow graph image of the given step
+// and substep, overlayed with the execution profile.
+func (p *funcPaths) profileImg(step int, subStep string) string {
+	return fmt.Sprintf("img/%s_step_%04d%s_profile.png", p.slug, step, subStep)
+}
+
+// domImg returns the name of the dominator tree image of the given step, where
+// kind is either "dom" or "post_dom".
+func (p *funcPaths) domImg(step int, kind string) string {
+	return fmt.Sprintf("img/%s_step_%04d_%s.png", p.slug, step, kind)
+}
+
+// --- [ Graphs and data ] -----------------------------------------------------
+
+// graphDir returns the directory of the graph and JSON files of the function,
+// within the control flow graph directory.
+func (p *funcPaths) graphDir() string {
+	return filepath.Join(p.dotDir, "funcs", p.slug)
+}
+
+// graph returns the path of the given graph or JSON file name of the function.
+func (p *funcPaths) graph(name string) string {
+	return filepath.Join(p.graphDir(), name)
+}
+
+// cfgDOT returns the path of the control flow graph in DOT format, which is the
+// input of restructure.
+func (p *funcPaths) cfgDOT() string {
+	return p.graph("cfg.dot")
+}
+
+// stepPNG returns the path of the control flow graph image of the given step
+// and substep, as output by restructure; or of the original control flow graph
+// on step 0.
+func (p *funcPaths) stepPNG(step int, subStep string) string {
+	if step == 0 {
+		return p.graph("cfg.png")
+	}
+	return p.graph(fmt.Sprintf("cfg_%04d%s.png", step, subStep))
+}
+
+// stepDOT returns the path of the control flow graph in DOT format of the
+// given step and substep, as output by restructure; or of the original control
+// flow graph on step 0.
+func (p *funcPaths) stepDOT(step int, subStep string) string {
+	if step == 0 {
+		return p.cfgDOT()
+	}
+	return p.graph(fmt.Sprintf("cfg_%04d%s.dot", step, subStep))
+}
+
+// primsJSON returns the path of the recovered control flow primitives in JSON
+// format, as output by restructure.
+func (p *funcPaths) primsJSON() string {
+	return p.graph("prims.json")
+}
+
+// residualDOT returns the path of the residual control flow graph in DOT
+// format.
+func (p *funcPaths) residualDOT() string {
+	return p.graph("residual.dot")
+}
+
+// loopsDOT returns the path of the loop nesting forest in DOT format.
+func (p *funcPaths) loopsDOT() string {
+	return p.graph("loops.dot")
+}
+
+// regionsDOT returns the path of the original control flow graph overlayed
+// with the regions of the recovered control flow primitives in DOT format.
+func (p *funcPaths) regionsDOT() string {
+	return p.graph("regions.dot")
+}
+
+// dfsDOT returns the path of the depth-first search spanning tree in DOT
+// format.
+func (p *funcPaths) dfsDOT() string {
+	return p.graph("dfs.dot")
+}
+
+// intervalsDOT returns the path of the given graph (1-based) of the derived
+// sequence of graphs in DOT format, with intervals drawn as clusters.
+func (p *funcPaths) intervalsDOT(n int) string {
+	return p.graph(fmt.Sprintf("intervals_g%d.dot", n))
+}
+
+// profileDOT returns the path of the control flow graph of the given step and
+// substep in DOT format, overlayed with the execution profile.
+func (p *funcPaths) profileDOT(step int, subStep string) string {
+	return p.graph(fmt.Sprintf("profile_%04d%s.dot", step, subStep))
+}
+
+// domDOT returns the path of the dominator tree of the given step in DOT
+// format, where kind is either "dom" or "post_dom".
+func (p *funcPaths) domDOT(step int, kind string) string {
+	return p.graph(fmt.Sprintf("%s_%04d.dot", kind, step))
+}
+
+// summaryJSON returns the path of the machine-readable summary of the
+// function.
+func (p *funcPaths) summaryJSON() string {
+	return filepath.Join(p.outputDir, "data", p.slug+".json")
+}
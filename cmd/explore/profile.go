@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/metadata"
+	"github.com/pkg/errors"
+)
+
+// blockCounts maps from function name to the execution count of each basic
+// block of the function, indexed by basic block name.
+type blockCounts map[string]map[string]uint64
+
+// parseBlockCounts parses the given block count file, as recorded by a
+// profiling run. Each line of the file specifies the execution count of a basic
+// block, as a function name, basic block name and count separated by
+// whitespace; e.g.
+//
+//    main entry 1
+//    main for.cond 11
+//
+// Function and basic block names are given without '@' and '%' prefix. Empty
+// lines and lines starting with '#' are ignored.
+func parseBlockCounts(countsPath string) (blockCounts, error) {
+	f, err := os.Open(countsPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	counts := make(blockCounts)
+	s := bufio.NewScanner(f)
+	for lineNum := 1; s.Scan(); lineNum++ {
+		line := strings.TrimSpace(s.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, errors.Errorf("%s:%d: invalid block count %q; expected FUNC BLOCK COUNT", countsPath, lineNum, line)
+		}
+		funcName, blockName := fields[0], fields[1]
+		count, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("%s:%d: invalid count %q of basic block %q", countsPath, lineNum, fields[2], blockName)
+		}
+		if counts[funcName] == nil {
+			counts[funcName] = make(map[string]uint64)
+		}
+		counts[funcName][blockName] += count
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return counts, nil
+}
+
+// branchWeights returns the branch weights of the terminator of the given basic
+// block, as specified by its !prof branch_weights metadata, in successor order
+// of the terminator. The boolean return value indicates whether the terminator
+// has valid branch weights.
+func branchWeights(block *ir.Block) ([]uint64, bool) {
+	term, ok := block.Term.(interface {
+		MDAttachments() []*metadata.Attachment
+	})
+	if !ok {
+		return nil, false
+	}
+	for _, md := range term.MDAttachments() {
+		if md.Name != "prof" {
+			continue
+		}
+		tuple, ok := md.Node.(*metadata.Tuple)
+		if !ok || len(tuple.Fields) < 1 {
+			continue
+		}
+		if name, ok := tuple.Fields[0].(*metadata.String); !ok || name.Value != "branch_weights" {
+			continue
+		}
+		var weights []uint64
+		for _, field := range tuple.Fields[1:] {
+			c, ok := field.(*constant.Int)
+			if !ok {
+				return nil, false
+			}
+			weights = append(weights, c.X.Uint64())
+		}
+		if len(weights) != len(block.Term.Succs()) {
+			return nil, false
+		}
+		return weights, true
+	}
+	return nil, false
+}
+
+// profile is the execution profile of a function, either measured from the
+// block counts of a profiling run, or estimated from branch weights.
+type profile struct {
+	// Execution frequency of each node; indexed by node.
+	freqs []float64
+	// Execution frequency of each edge; indexed by from and to node.
+	edgeFreqs map[[2]int]float64
+	// Specifies whether the frequencies are measured block counts, rather than
+	// estimated from branch weights relative to one execution of the entry
+	// node.
+	measured bool
+}
+
+// newProfile returns the execution profile of the given control flow graph;
+// or nil if neither block counts nor branch weights are present.
+//
+// Edge probabilities are given by branch weights if present; otherwise they
+// are proportional to the block counts of the successors, or uniform if no
+// block counts are present.
+//
+// - counts specifies the execution count of each basic block, indexed by basic
+//   block name; or nil if not present.
+func newProfile(g *cfg, counts map[string]uint64) *profile {
+	hasWeights := false
+	for _, block := range g.f.Blocks {
+		if _, ok := branchWeights(block); ok {
+			hasWeights = true
+			break
+		}
+	}
+	if len(counts) == 0 && !hasWeights {
+		return nil
+	}
+	probs := edgeProbs(g, counts)
+	prof := &profile{
+		freqs:     make([]float64, len(g.names)),
+		edgeFreqs: make(map[[2]int]float64),
+		measured:  len(counts) > 0,
+	}
+	if prof.measured {
+		for n, name := range g.names {
+			prof.freqs[n] = float64(counts[name])
+		}
+	} else {
+		prof.freqs = estimateFreqs(g, probs)
+	}
+	for edge, prob := range probs {
+		prof.edgeFreqs[edge] = prof.freqs[edge[0]] * prob
+	}
+	return prof
+}
+
+// edgeProbs returns the probability of each edge of the control flow graph,
+// indexed by from and to node.
+//
+// - counts specifies the execution count of each basic block, indexed by basic
+//   block name; or nil if not present.
+func edgeProbs(g *cfg, counts map[string]uint64) map[[2]int]float64 {
+	probs := make(map[[2]int]float64)
+	for from, block := range g.f.Blocks {
+		succs := g.succs[from]
+		if weights, ok := branchWeights(block); ok {
+			// Note, a switch terminator may have several cases with the same
+			// target, so the weights of each target are summed.
+			total := uint64(0)
+			for _, w := range weights {
+				total += w
+			}
+			if total > 0 {
+				for i, succ := range block.Term.Succs() {
+					to := g.index[succ.Name()]
+					probs[[2]int{from, to}] += float64(weights[i]) / float64(total)
+				}
+				continue
+			}
+		}
+		total := uint64(0)
+		for _, to := range succs {
+			total += counts[g.names[to]]
+		}
+		for _, to := range succs {
+			if total > 0 {
+				probs[[2]int{from, to}] = float64(counts[g.names[to]]) / float64(total)
+			} else {
+				probs[[2]int{from, to}] = 1 / float64(len(succs))
+			}
+		}
+	}
+	return probs
+}
+
+// estimateFreqs estimates the execution frequency of each node of the control
+// flow graph relative to one execution of the entry node, by solving the flow
+// equations
+//
+//    freq(n) = [n is entry] + sum(freq(p) * prob(p -> n)) for predecessors p
+//
+// using Gaussian elimination. Nodes of loops that never exit (as given by the
+// edge probabilities) have unbounded frequencies, in which case a fixed number
+// of iterations is used instead.
+func estimateFreqs(g *cfg, probs map[[2]int]float64) []float64 {
+	n := len(g.names)
+	// Augmented matrix of (I - P^T) freq = e_entry.
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = make([]float64, n+1)
+		a[i][i] = 1
+	}
+	if n > 0 {
+		a[0][n] = 1
+	}
+	for edge, prob := range probs {
+		from, to := edge[0], edge[1]
+		a[to][from] -= prob
+	}
+	if freqs, ok := solve(a); ok {
+		return freqs
+	}
+	// Unbounded frequencies; iterate flow equations.
+	freqs := make([]float64, n)
+	for iter := 0; iter < 100; iter++ {
+		next := make([]float64, n)
+		if n > 0 {
+			next[0] = 1
+		}
+		for edge, prob := range probs {
+			next[edge[1]] += freqs[edge[0]] * prob
+		}
+		freqs = next
+	}
+	return freqs
+}
+
+// solve solves the linear equation system of the given augmented matrix using
+// Gaussian elimination with partial pivoting. The boolean return value
+// indicates whether the system has a unique non-negative solution.
+func solve(a [][]float64) ([]float64, bool) {
+	const eps = 1e-12
+	n := len(a)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < eps {
+			return nil, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for row := 0; row < n; row++ {
+			if row == col || a[row][col] == 0 {
+				continue
+			}
+			factor := a[row][col] / a[col][col]
+			for k := col; k <= n; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = a[i][n] / a[i][i]
+		if x[i] < -eps || math.IsInf(x[i], 0) || math.IsNaN(x[i]) {
+			return nil, false
+		}
+		if x[i] < 0 {
+			x[i] = 0
+		}
+	}
+	return x, true
+}
+
+// maxFreq returns the maximum node frequency of the profile.
+func (prof *profile) maxFreq() float64 {
+	max := 0.0
+	for _, freq := range prof.freqs {
+		max = math.Max(max, freq)
+	}
+	return max
+}
+
+// formatFreq returns a human-readable representation of the given frequency;
+// an execution count if measured, or relative to one execution of the entry
+// node if estimated (e.g. "×2.5").
+func (prof *profile) formatFreq(freq float64) string {
+	if prof.measured {
+		return strconv.FormatFloat(math.Round(freq), 'f', -1, 64)
+	}
+	return "×" + strconv.FormatFloat(freq, 'g', 3, 64)
+}
+
+// heatColor returns the heat color of the given frequency relative to the
+// maximum frequency, on a logarithmic scale from white (cold) to red (hot).
+func heatColor(freq, max float64) string {
+	t := 0.0
+	if max > 0 {
+		t = math.Log1p(freq) / math.Log1p(max)
+	}
+	t = math.Max(0, math.Min(1, t))
+	r := 255 - 40*t
+	g := 255 - 207*t
+	b := 255 - 216*t
+	return fmt.Sprintf("#%02x%02x%02x", int(r), int(g), int(b))
+}
+
+// profileDOT returns a representation of the control flow graph of the given
+// intermediate step in Graphviz DOT format, with block heat colors and edge
+// thickness given by the execution profile. The nodes of the recovered control
+// flow primitives are merged in order, as in the control flow graph output by
+// restructure; the frequency of a merged node is the maximum frequency of its
+// original basic blocks, and edges between merged nodes sum the frequencies of
+// the original edges.
+//
+// - merged specifies the original basic blocks contained within each node of
+//   the intermediate step, as returned by mergedBlocks.
+func profileDOT(g *cfg, prof *profile, merged map[string][]string) string {
+	// Merged node of each original basic block; indexed by node.
+	nodeOf := make([]string, len(g.names))
+	for name, blocks := range merged {
+		for _, n := range g.nodes(blocks) {
+			nodeOf[n] = name
+		}
+	}
+	freqs := make(map[string]float64)
+	for n, freq := range prof.freqs {
+		name := nodeOf[n]
+		freqs[name] = math.Max(freqs[name], freq)
+	}
+	type edge struct {
+		from, to string
+	}
+	var edges []edge
+	edgeFreqs := make(map[edge]float64)
+	maxEdge := 0.0
+	for from, succs := range g.succs {
+		for _, to := range succs {
+			e := edge{from: nodeOf[from], to: nodeOf[to]}
+			if e.from == e.to && len(merged[e.from]) > 1 {
+				// Edge within merged node.
+				continue
+			}
+			if _, ok := edgeFreqs[e]; !ok {
+				edges = append(edges, e)
+			}
+			edgeFreqs[e] += prof.edgeFreqs[[2]int{from, to}]
+			maxEdge = math.Max(maxEdge, edgeFreqs[e])
+		}
+	}
+	max := prof.maxFreq()
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "digraph %s {\n", dotQuote(g.f.Name()))
+	buf.WriteString("\tnode [style=filled]\n")
+	for n, name := range g.names {
+		if nodeOf[n] != name {
+			// Basic block merged into other node.
+			continue
+		}
+		freq := freqs[name]
+		label := fmt.Sprintf("%s\n%s", name, prof.formatFreq(freq))
+		fmt.Fprintf(buf, "\t%s [label=%s fillcolor=%s", dotQuote(name), dotQuote(label), dotQuote(heatColor(freq, max)))
+		if n == 0 {
+			buf.WriteString(" peripheries=2")
+		}
+		buf.WriteString("]\n")
+	}
+	for _, e := range edges {
+		freq := edgeFreqs[e]
+		width := 1.0
+		if maxEdge > 0 {
+			width += 5 * freq / maxEdge
+		}
+		fmt.Fprintf(buf, "\t%s -> %s [label=%s penwidth=%.2f]\n", dotQuote(e.from), dotQuote(e.to), dotQuote(prof.formatFreq(freq)), width)
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
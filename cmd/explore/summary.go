@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mewkiz/pkg/jsonutil"
+	"github.com/mewmew/lnp/pkg/cfa/primitive"
+	"github.com/pkg/errors"
+)
+
+// funcSummary is a machine-readable summary of the visualization of a
+// function, which is stored in the data subdirectory of the explore output
+// directory, and used to compare explorations.
+type funcSummary struct {
+	// Function name.
+	Name string `json:"name"`
+	// Function name slug used in output file names.
+	Slug string `json:"slug"`
+	// Number of basic blocks.
+	NBlocks int `json:"nblocks"`
+	// Recovered control flow primitives.
+	Prims []*primitive.Primitive `json:"prims"`
+	// Reconstructed Go source code after each step, where index 0 holds the Go
+	// source code of step 0.
+	GoSources []string `json:"go_sources"`
+	// Number of nodes remaining in the control flow graph after merging all
+	// recovered control flow primitives.
+	NResidual int `json:"nresidual"`
+}
+
+// outputSummary outputs the machine-readable summary of the visualization of
+// the given function.
+func (e *explorer) outputSummary(summary *funcSummary) error {
+	jsonPath := e.paths(summary.Name).summaryJSON()
+	if err := os.MkdirAll(filepath.Dir(jsonPath), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	dbg.Printf("creating file %q", jsonPath)
+	if err := jsonutil.WriteFile(jsonPath, summary); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// parseSummaries parses the machine-readable summaries of the visualized
+// functions of the given explore output directory; indexed by function name.
+func parseSummaries(outputDir string) (map[string]*funcSummary, error) {
+	jsonPaths, err := filepath.Glob(filepath.Join(outputDir, "data", "*.json"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	summaries := make(map[string]*funcSummary)
+	for _, jsonPath := range jsonPaths {
+		summary := &funcSummary{}
+		if err := jsonutil.ParseFile(jsonPath, summary); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		summaries[summary.Name] = summary
+	}
+	return summaries, nil
+}
+
+// finalGoSource returns the reconstructed Go source code after the last step.
+func (summary *funcSummary) finalGoSource() string {
+	if len(summary.GoSources) == 0 {
+		return ""
+	}
+	return summary.GoSources[len(summary.GoSources)-1]
+}
+
+// primDesc returns a one-line description of the given control flow primitive;
+// e.g.
+//
+//    if (body=17, cond=12, exit=24)
+func primDesc(prim *primitive.Primitive) string {
+	var roles []string
+	for role := range prim.Nodes {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	var nodes []string
+	for _, role := range roles {
+		nodes = append(nodes, fmt.Sprintf("%s=%s", role, prim.Nodes[role]))
+	}
+	return fmt.Sprintf("%s (%s)", prim.Prim, strings.Join(nodes, ", "))
+}
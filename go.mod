@@ -2,6 +2,7 @@ module github.com/mewmew/explore
 
 require (
 	github.com/alecthomas/chroma v0.6.2
+	github.com/ianlancetaylor/demangle v0.0.0-20260724033716-83e58baca724
 	github.com/llir/llvm v0.3.0-pre6.0.20190103125316-54e79f336001
 	github.com/mewkiz/pkg v0.0.0-20181231041609-5720a0c5985d
 	github.com/mewmew/lnp v0.0.0-20190103125913-7e33f0db0930
@@ -0,0 +1,25 @@
+// set_cfa_view sets the view of the control flow analysis pane; one of "graph",
+// "profile" and "raw". The view is persisted in local storage.
+function set_cfa_view(view) {
+	localStorage.setItem("cfa_view", view);
+	update_cfa_view();
+}
+
+// update_cfa_view displays the active view of the control flow analysis pane.
+function update_cfa_view() {
+	var view = localStorage.getItem("cfa_view");
+	if (view === null || document.getElementById("cfa_" + view) === null) {
+		view = "graph";
+	}
+	var views = ["graph", "profile", "raw"];
+	for (var i = 0; i < views.length; i++) {
+		var elem = document.getElementById("cfa_" + views[i]);
+		if (elem !== null) {
+			elem.style.display = (views[i] == view) ? "block" : "none";
+		}
+		var link = document.getElementById("cfa_view_" + views[i]);
+		if (link !== null) {
+			link.className = (views[i] == view) ? "current" : "";
+		}
+	}
+}
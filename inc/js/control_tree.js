@@ -0,0 +1,13 @@
+// select_control_node selects the given node of the control tree, highlighting
+// its basic blocks in the LLVM IR assembly and original C source code panes.
+//
+// highlights maps from control tree node ID to the line ranges to highlight,
+// as specified by the control tree page.
+function select_control_node(id) {
+	var nodes = document.querySelectorAll("a.control_node");
+	for (var i = 0; i < nodes.length; i++) {
+		nodes[i].classList.toggle("selected", nodes[i].id == "control_node_" + id);
+	}
+	post_highlight("frame_llvm", highlights[id].llvm);
+	post_highlight("frame_c", highlights[id].c);
+}
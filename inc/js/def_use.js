@@ -0,0 +1,93 @@
+// init_def_use makes each SSA value of the LLVM IR assembly clickable. Clicking
+// a value highlights its definition and all its uses.
+//
+// def_use maps from local identifier (e.g. "%x") to its def-use chain, as
+// computed from the LLVM IR value graph by the explore tool.
+//
+// func_lines is the line range [start, end] of the analyzed function, outside
+// of which local identifiers refer to values of other functions.
+function init_def_use(def_use, func_lines) {
+	var tokens = get_leaf_tokens();
+	number_tokens(tokens);
+	for (var i = 0; i < tokens.length; i++) {
+		var token = tokens[i];
+		var ident = token.textContent;
+		if (!def_use.hasOwnProperty(ident) || !in_lines(token, func_lines)) {
+			continue;
+		}
+		token.classList.add("ssa_value");
+		token.addEventListener("click", function(event) {
+			select_value(def_use, event.target.textContent, func_lines);
+		});
+	}
+}
+
+// select_value highlights the definition and uses of the given SSA value.
+function select_value(def_use, ident, func_lines) {
+	clear_def_use();
+	var chain = def_use[ident];
+	var uses = chain.uses || [];
+	// Highlight tokens of the value.
+	var tokens = get_leaf_tokens();
+	for (var i = 0; i < tokens.length; i++) {
+		if (tokens[i].textContent == ident && in_lines(tokens[i], func_lines)) {
+			tokens[i].classList.add("ssa_selected");
+		}
+	}
+	// Highlight line numbers of the definition and uses.
+	var lineNumbers = document.querySelectorAll("span.lnt");
+	var mark = function(line, className) {
+		if (line >= 1 && line <= lineNumbers.length) {
+			lineNumbers[line-1].classList.add(className);
+		}
+	};
+	mark(chain.def.line, "ssa_def");
+	for (var i = 0; i < uses.length; i++) {
+		mark(uses[i].line, "ssa_use");
+	}
+	// Describe def-use chain.
+	var info = document.getElementById("def_use_info");
+	var desc = ident + " defined at line " + chain.def.line + format_block(chain.def.block);
+	if (uses.length == 0) {
+		desc += "; not used.";
+	} else {
+		var refs = [];
+		for (var i = 0; i < uses.length; i++) {
+			refs.push(uses[i].line + format_block(uses[i].block));
+		}
+		desc += "; used at line " + refs.join(", ") + ".";
+	}
+	info.textContent = desc;
+	info.style.display = "block";
+}
+
+// clear_def_use clears the highlighting of the selected SSA value.
+function clear_def_use() {
+	var classNames = ["ssa_selected", "ssa_def", "ssa_use"];
+	for (var i = 0; i < classNames.length; i++) {
+		var elems = document.querySelectorAll("." + classNames[i]);
+		for (var j = 0; j < elems.length; j++) {
+			elems[j].classList.remove(classNames[i]);
+		}
+	}
+}
+
+// format_block returns a description of the given basic block name.
+function format_block(block) {
+	if (block.length == 0) {
+		return " (function header)";
+	}
+	return " (block " + block + ")";
+}
+
+// get_leaf_tokens returns the syntax highlighted tokens of the source code.
+function get_leaf_tokens() {
+	var tokens = [];
+	var spans = document.querySelectorAll("pre span");
+	for (var i = 0; i < spans.length; i++) {
+		if (spans[i].children.length == 0 && !spans[i].classList.contains("lnt")) {
+			tokens.push(spans[i]);
+		}
+	}
+	return tokens;
+}
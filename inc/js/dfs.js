@@ -0,0 +1,114 @@
+// Animation of the depth-first search of a function.
+var dfs_events = [];
+var dfs_pos = 0;
+var dfs_timer = null;
+
+// Delay in milliseconds between events when playing the animation.
+var dfs_delay = 800;
+
+// init_dfs initializes the animation of the given depth-first search events,
+// as specified by the depth-first search page. The animation starts after the
+// last event, presenting the complete DFS spanning tree.
+function init_dfs(events) {
+	dfs_events = events === null ? [] : events;
+	document.getElementById("dfs_slider").max = dfs_events.length;
+	dfs_goto(dfs_events.length);
+}
+
+// dfs_step moves the given number of events forwards (or backwards if
+// negative) in the animation.
+function dfs_step(delta) {
+	dfs_goto(dfs_pos + delta);
+}
+
+// dfs_play starts or pauses playing the animation.
+function dfs_play() {
+	var button = document.getElementById("dfs_play");
+	if (dfs_timer !== null) {
+		clearInterval(dfs_timer);
+		dfs_timer = null;
+		button.textContent = "▶";
+		return;
+	}
+	if (dfs_pos >= dfs_events.length) {
+		dfs_goto(0);
+	}
+	button.textContent = "⏸";
+	dfs_timer = setInterval(function() {
+		if (dfs_pos >= dfs_events.length) {
+			dfs_play();
+			return;
+		}
+		dfs_step(1);
+	}, dfs_delay);
+}
+
+// dfs_goto presents the state of the depth-first search after the given number
+// of events; nodes are unvisited, active (on the DFS stack) or finished, and
+// edges are unexamined or classified.
+function dfs_goto(pos) {
+	pos = Math.max(0, Math.min(pos, dfs_events.length));
+	dfs_pos = pos;
+	document.getElementById("dfs_slider").value = pos;
+	var status = "before search";
+	if (pos > 0) {
+		status = "event " + pos + " of " + dfs_events.length + ": " + dfs_events[pos-1].desc;
+	}
+	document.getElementById("dfs_status").textContent = status;
+	// Replay events.
+	var node_states = {};
+	var examined = {};
+	for (var i = 0; i < dfs_events.length; i++) {
+		var event = dfs_events[i];
+		switch (event.kind) {
+		case "visit":
+			node_states[event.from] = "dfs_unvisited";
+			break;
+		case "edge":
+			examined[event.from + "_" + event.to] = false;
+			break;
+		}
+	}
+	for (var i = 0; i < pos; i++) {
+		var event = dfs_events[i];
+		switch (event.kind) {
+		case "visit":
+			node_states[event.from] = "dfs_active";
+			break;
+		case "edge":
+			examined[event.from + "_" + event.to] = true;
+			break;
+		case "finish":
+			node_states[event.from] = "dfs_finished";
+			break;
+		}
+	}
+	var current = pos > 0 ? dfs_events[pos-1] : null;
+	var classes = ["dfs_unvisited", "dfs_active", "dfs_finished"];
+	for (var node in node_states) {
+		var elem = document.getElementById("dfs_node_" + node);
+		if (elem === null) {
+			continue;
+		}
+		for (var i = 0; i < classes.length; i++) {
+			elem.classList.toggle(classes[i], classes[i] === node_states[node]);
+		}
+		elem.classList.toggle("dfs_current", current !== null && current.kind !== "edge" && current.from == node);
+	}
+	for (var edge in examined) {
+		var elem = document.getElementById("dfs_edge_" + edge);
+		if (elem === null) {
+			continue;
+		}
+		elem.classList.toggle("dfs_unexamined", !examined[edge]);
+		elem.classList.toggle("dfs_current", current !== null && current.kind === "edge" && current.from + "_" + current.to === edge);
+	}
+	// Highlight current event in the list of events.
+	for (var i = 0; i < dfs_events.length; i++) {
+		var item = document.getElementById("dfs_event_" + i);
+		item.classList.toggle("dfs_current", i === pos-1);
+		if (i === pos-1 && dfs_timer !== null) {
+			item.scrollIntoView({block: "nearest"});
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Replay of the executed basic block trace of a function.
+var exec_trace = [];
+var exec_highlights = {};
+var exec_pos = 0;
+var exec_timer = null;
+
+// Delay in milliseconds between basic blocks when playing the trace.
+var exec_delay = 600;
+
+// init_exec initializes the replay of the given executed basic block trace.
+//
+// trace is the list of executed basic block names, and highlights maps from
+// basic block name to the line ranges to highlight, as specified by the
+// execution trace page.
+function init_exec(trace, highlights) {
+	exec_trace = trace === null ? [] : trace;
+	exec_highlights = highlights;
+	var slider = document.getElementById("exec_slider");
+	slider.max = Math.max(exec_trace.length-1, 0);
+	exec_goto(0);
+}
+
+// exec_step moves the given number of basic blocks forwards (or backwards if
+// negative) in the trace.
+function exec_step(delta) {
+	exec_goto(exec_pos + delta);
+}
+
+// exec_play starts or pauses playing the trace.
+function exec_play() {
+	var button = document.getElementById("exec_play");
+	if (exec_timer !== null) {
+		clearInterval(exec_timer);
+		exec_timer = null;
+		button.textContent = "▶";
+		return;
+	}
+	if (exec_pos >= exec_trace.length-1) {
+		exec_goto(0);
+	}
+	button.textContent = "⏸";
+	exec_timer = setInterval(function() {
+		if (exec_pos >= exec_trace.length-1) {
+			exec_play();
+			return;
+		}
+		exec_step(1);
+	}, exec_delay);
+}
+
+// exec_goto moves to the given position in the trace, highlighting the current
+// basic block in the control flow graph, LLVM IR assembly, original C source
+// code and reconstructed Go source code panes.
+function exec_goto(pos) {
+	if (exec_trace.length === 0) {
+		document.getElementById("exec_status").textContent = "no basic blocks executed";
+		return;
+	}
+	pos = Math.max(0, Math.min(pos, exec_trace.length-1));
+	exec_pos = pos;
+	var block = exec_trace[pos];
+	var prev = pos > 0 ? exec_trace[pos-1] : null;
+	document.getElementById("exec_slider").value = pos;
+	var visits = 0;
+	for (var i = 0; i <= pos; i++) {
+		if (exec_trace[i] === block) {
+			visits++;
+		}
+	}
+	document.getElementById("exec_status").textContent = "step " + (pos+1) + " of " + exec_trace.length + ": basic block %" + block + " (visit " + visits + ")";
+	// Highlight current node and the edge taken to reach it in the control flow
+	// graph.
+	var nodes = document.querySelectorAll("#exec_cfg g.node");
+	for (var i = 0; i < nodes.length; i++) {
+		nodes[i].classList.toggle("exec_current", svg_title(nodes[i]) === block);
+	}
+	var edges = document.querySelectorAll("#exec_cfg g.edge");
+	for (var i = 0; i < edges.length; i++) {
+		edges[i].classList.toggle("exec_current", prev !== null && svg_title(edges[i]) === prev + "->" + block);
+	}
+	var h = exec_highlights[block];
+	if (h === undefined) {
+		h = {llvm: null, c: null};
+	}
+	post_highlight("frame_llvm", h.llvm);
+	post_highlight("frame_c", h.c);
+	post_highlight("frame_go", h.go === undefined ? null : h.go);
+}
+
+// svg_title returns the title of the given node or edge of a Graphviz SVG
+// image; i.e. the node name or "from->to".
+function svg_title(elem) {
+	var title = elem.querySelector("title");
+	if (title === null) {
+		return "";
+	}
+	return title.textContent;
+}
@@ -0,0 +1,25 @@
+// set_go_view sets the view of the Go pane; one of "source", "unified" and
+// "side_by_side". The view is persisted in local storage.
+function set_go_view(view) {
+	localStorage.setItem("go_view", view);
+	update_go_view();
+}
+
+// update_go_view displays the active view of the Go pane.
+function update_go_view() {
+	var view = localStorage.getItem("go_view");
+	if (view === null || document.getElementById("go_" + view) === null) {
+		view = "source";
+	}
+	var views = ["source", "unified", "side_by_side"];
+	for (var i = 0; i < views.length; i++) {
+		var elem = document.getElementById("go_" + views[i]);
+		if (elem !== null) {
+			elem.style.display = (views[i] == view) ? "block" : "none";
+		}
+		var link = document.getElementById("go_view_" + views[i]);
+		if (link !== null) {
+			link.className = (views[i] == view) ? "current" : "";
+		}
+	}
+}
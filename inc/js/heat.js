@@ -0,0 +1,19 @@
+// init_heat colors the line numbers of the LLVM IR assembly by the execution
+// profile, forming a heat gutter.
+//
+// heat is a list of line ranges with heat color and title; or null if no
+// execution profile is present.
+function init_heat(heat) {
+	if (heat === null) {
+		return;
+	}
+	var line_numbers = document.querySelectorAll("span.lnt");
+	for (var i = 0; i < heat.length; i++) {
+		var h = heat[i];
+		for (var line = h.lines[0]; line <= h.lines[1] && line <= line_numbers.length; line++) {
+			var elem = line_numbers[line-1];
+			elem.style.borderLeft = "6px solid " + h.color;
+			elem.title = h.title;
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// add_highlight_event_listener adds an event listener to handle events which
+// highlight line ranges, as sent by the control tree, execution trace and
+// interval analysis pages.
+function add_highlight_event_listener() {
+	window.addEventListener("message", function(event) {
+		if (event.data !== null && typeof event.data === "object" && event.data.highlight_lines !== undefined) {
+			highlight_lines(event.data.highlight_lines, event.data.header_lines);
+		}
+	});
+}
+
+// highlight_lines marks the line numbers of the given line ranges, and scrolls
+// the first highlighted line into view.
+//
+// lines is a list of line ranges [start, end] (1-based); or null. header_lines
+// is an optional list of line ranges marked as header lines (e.g. of the header
+// basic block of an interval); or null.
+function highlight_lines(lines, header_lines) {
+	if (lines === null) {
+		lines = [];
+	}
+	if (header_lines === undefined || header_lines === null) {
+		header_lines = [];
+	}
+	var line_numbers = document.querySelectorAll("span.lnt");
+	var first = null;
+	for (var i = 0; i < line_numbers.length; i++) {
+		var line = i + 1;
+		var selected = in_ranges(line, lines);
+		var header = in_ranges(line, header_lines);
+		line_numbers[i].classList.toggle("line_selected", selected);
+		line_numbers[i].classList.toggle("line_header", header);
+		if ((selected || header) && first === null) {
+			first = line_numbers[i];
+		}
+	}
+	if (first !== null) {
+		first.scrollIntoView({block: "center"});
+	}
+}
+
+// in_ranges reports whether the given line is within any of the line ranges
+// [start, end].
+function in_ranges(line, ranges) {
+	for (var j = 0; j < ranges.length; j++) {
+		if (ranges[j][0] <= line && line <= ranges[j][1]) {
+			return true;
+		}
+	}
+	return false;
+}
+
+// post_highlight sends an event to the given frame, notifying it to highlight
+// the specified line ranges, and optionally header line ranges. This
+// indirection is used because same-origin policy prevent direct manupulation of
+// the DOM of frames on the file:// scheme.
+function post_highlight(frame_id, lines, header_lines) {
+	var frame = document.getElementById(frame_id);
+	if (frame === null) {
+		return;
+	}
+	if (header_lines === undefined) {
+		header_lines = null;
+	}
+	frame.contentWindow.postMessage({highlight_lines: lines, header_lines: header_lines}, "*");
+}
@@ -0,0 +1,31 @@
+// Lines to highlight for each interval of the interval analysis page.
+var interval_highlights = [];
+
+// init_intervals initializes the interval analysis page, selecting the first
+// interval.
+//
+// highlights is the list of line ranges to highlight for each interval, as
+// specified by the interval analysis page.
+function init_intervals(highlights) {
+	interval_highlights = highlights === null ? [] : highlights;
+	if (interval_highlights.length > 0) {
+		select_interval(0);
+	}
+}
+
+// select_interval selects the given interval, highlighting it in the graph and
+// its header and member basic blocks in the LLVM IR assembly and original C
+// source code panes.
+function select_interval(id) {
+	var links = document.querySelectorAll("a.interval_link");
+	for (var i = 0; i < links.length; i++) {
+		links[i].classList.toggle("selected", links[i].id == "interval_link_" + id);
+	}
+	var clusters = document.querySelectorAll("#intervals_graph g.cluster");
+	for (var i = 0; i < clusters.length; i++) {
+		clusters[i].classList.toggle("interval_selected", clusters[i].id == "interval_" + id);
+	}
+	var h = interval_highlights[id];
+	post_highlight("frame_llvm", h.members.llvm, h.header.llvm);
+	post_highlight("frame_c", h.members.c, h.header.c);
+}
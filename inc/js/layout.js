@@ -0,0 +1,156 @@
+// Layouts of the panes of the overview page:
+//
+//    columns: all panes side by side.
+//    grid:    panes in a 2x2 grid.
+//    tabs:    one pane at the time, selected by tab.
+//    pairs:   two adjacent panes side by side, selected by tab.
+var layouts = ["columns", "grid", "tabs", "pairs"];
+
+// set_layout sets the layout of the panes of the overview page. The layout is
+// persisted in local storage.
+function set_layout(layout) {
+	localStorage.setItem("layout", layout);
+	update_layout();
+}
+
+// select_layout updates the active layout based on the selected layout.
+function select_layout() {
+	var elem = document.getElementById("layout_selection");
+	set_layout(elem.value);
+}
+
+// get_layout returns the layout in use.
+function get_layout() {
+	var layout = localStorage.getItem("layout");
+	if (layout === null || layouts.indexOf(layout) === -1) {
+		return "columns";
+	}
+	return layout;
+}
+
+// get_panes returns the panes of the overview page, in display order.
+function get_panes() {
+	return Array.prototype.slice.call(document.querySelectorAll("#panes div.pane"));
+}
+
+// get_tabs returns the tabs of the given layout, where each tab is the list of
+// panes displayed when the tab is selected; or null if the layout has no tabs.
+function get_tabs(layout, panes) {
+	var tabs = [];
+	switch (layout) {
+	case "tabs":
+		for (var i = 0; i < panes.length; i++) {
+			tabs.push([panes[i]]);
+		}
+		return tabs;
+	case "pairs":
+		for (var i = 0; i+1 < panes.length; i++) {
+			tabs.push([panes[i], panes[i+1]]);
+		}
+		return tabs;
+	}
+	return null;
+}
+
+// set_tab sets the active tab of the tabs and pairs layouts. The tab is
+// persisted in local storage.
+function set_tab(tab) {
+	localStorage.setItem("layout_tab_" + get_layout(), tab);
+	update_layout();
+}
+
+// get_collapsed returns the set of collapsed panes, mapping from pane ID to
+// true.
+function get_collapsed() {
+	var collapsed = localStorage.getItem("collapsed_panes");
+	if (collapsed === null) {
+		return {};
+	}
+	return JSON.parse(collapsed);
+}
+
+// toggle_pane collapses or expands the given pane. The set of collapsed panes
+// is persisted in local storage.
+function toggle_pane(id) {
+	var collapsed = get_collapsed();
+	if (collapsed[id]) {
+		delete collapsed[id];
+	} else {
+		collapsed[id] = true;
+	}
+	localStorage.setItem("collapsed_panes", JSON.stringify(collapsed));
+	update_layout();
+}
+
+// get_heights returns the pane heights of the given layout, as resized by the
+// user, mapping from pane ID to CSS height.
+function get_heights(layout) {
+	var heights = localStorage.getItem("pane_heights_" + layout);
+	if (heights === null) {
+		return {};
+	}
+	return JSON.parse(heights);
+}
+
+// add_pane_resize_listener adds an event listener which persists the heights
+// of resized panes in local storage.
+function add_pane_resize_listener() {
+	document.addEventListener("mouseup", function(event) {
+		var layout = get_layout();
+		var heights = get_heights(layout);
+		var panes = get_panes();
+		for (var i = 0; i < panes.length; i++) {
+			var body = panes[i].querySelector("div.pane_body");
+			if (body.style.height !== "") {
+				heights[panes[i].id] = body.style.height;
+			}
+		}
+		localStorage.setItem("pane_heights_" + layout, JSON.stringify(heights));
+	});
+}
+
+// update_layout displays the panes of the overview page using the active
+// layout.
+function update_layout() {
+	var layout = get_layout();
+	var elem = document.getElementById("layout_selection");
+	elem.value = layout;
+	document.getElementById("panes").className = "layout_" + layout;
+	var panes = get_panes();
+	// Display the panes of the active tab.
+	var visible = panes;
+	var tab_bar = document.getElementById("pane_tabs");
+	tab_bar.innerHTML = "";
+	var tabs = get_tabs(layout, panes);
+	if (tabs !== null) {
+		var tab = parseInt(localStorage.getItem("layout_tab_" + layout), 10);
+		if (isNaN(tab) || tab < 0 || tab >= tabs.length) {
+			tab = 0;
+		}
+		visible = tabs[tab];
+		for (var i = 0; i < tabs.length; i++) {
+			var titles = [];
+			for (var j = 0; j < tabs[i].length; j++) {
+				titles.push(tabs[i][j].dataset.title);
+			}
+			var link = document.createElement("a");
+			link.textContent = titles.join(" / ");
+			link.className = (i == tab) ? "current" : "";
+			link.setAttribute("onclick", "set_tab(" + i + ");");
+			tab_bar.appendChild(link);
+		}
+	}
+	tab_bar.style.display = (tabs !== null) ? "block" : "none";
+	// Collapse panes and restore resized heights.
+	var collapsed = get_collapsed();
+	var heights = get_heights(layout);
+	for (var i = 0; i < panes.length; i++) {
+		var pane = panes[i];
+		var is_collapsed = collapsed[pane.id] === true && tabs === null;
+		pane.style.display = (visible.indexOf(pane) !== -1) ? "" : "none";
+		pane.classList.toggle("collapsed", is_collapsed);
+		pane.querySelector("a.pane_toggle").textContent = is_collapsed ? "+" : "−";
+		var body = pane.querySelector("div.pane_body");
+		body.style.height = (pane.id in heights) ? heights[pane.id] : "";
+	}
+}
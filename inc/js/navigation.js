@@ -0,0 +1,64 @@
+// init_navigation initializes the step navigation of the overview page; jumping
+// to a given step, and to the previous or next step of a given primitive (e.g.
+// the next loop). The selected primitive is persisted in local storage.
+//
+// steps is the list of steps of the function, as specified by the steps
+// JavaScript file of the function.
+//
+// cur_page is the page number of the current overview page.
+function init_navigation(steps, cur_page) {
+	var elem = document.getElementById("prim_selection");
+	var seen = {};
+	for (var i = 0; i < steps.length; i++) {
+		var prim = steps[i].prim;
+		if (prim === "" || seen[prim]) {
+			continue;
+		}
+		seen[prim] = true;
+		var option = document.createElement("option");
+		option.value = prim;
+		option.textContent = prim;
+		elem.appendChild(option);
+	}
+	var prim = localStorage.getItem("nav_prim");
+	if (prim !== null && seen[prim]) {
+		elem.value = prim;
+	}
+	elem.addEventListener("change", function() {
+		localStorage.setItem("nav_prim", elem.value);
+	});
+	window.nav_cur_page = cur_page;
+}
+
+// jump_to_step navigates to the overview page of the step specified by the
+// jump input field; e.g. "3", "3a" or "3b". A step without substep denotes the
+// step after merge.
+function jump_to_step() {
+	var elem = document.getElementById("jump_step");
+	var query = elem.value.trim().toLowerCase();
+	if (/^\d+$/.test(query) && query !== "0") {
+		query += "b";
+	}
+	// Normalize leading zeros.
+	query = query.replace(/^0+(?=\d)/, "");
+	for (var i = 0; i < steps.length; i++) {
+		if (steps[i].step == query) {
+			window.location.href = steps[i].link;
+			return;
+		}
+	}
+	elem.classList.add("invalid");
+}
+
+// goto_prim navigates to the previous (dir = -1) or next (dir = 1) step which
+// recovered the selected primitive.
+function goto_prim(dir) {
+	var prim = document.getElementById("prim_selection").value;
+	for (var i = window.nav_cur_page - 1 + dir; i >= 0 && i < steps.length; i += dir) {
+		// Skip the step before merge of the current primitive.
+		if (steps[i].prim == prim && steps[i].step.slice(0, -1) != steps[window.nav_cur_page - 1].step.slice(0, -1)) {
+			window.location.href = steps[i].link;
+			return;
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// init_refs makes references to top-level entities (functions, globals, type
+// definitions, attribute groups and metadata) of the LLVM IR assembly link to
+// their definitions, and scrolls the analyzed function into view; or the line
+// specified by the URL fragment, which is either the identifier of a top-level
+// entity (e.g. "#@foo") or an anchor of the analyzed function (e.g.
+// "#bb:entry" or "#inst:entry:2").
+//
+// refs maps from identifier (e.g. "@foo") to the line number of its
+// definition; or null if the LLVM IR assembly contains only the analyzed
+// function.
+//
+// anchors maps from anchor name of basic blocks and instructions of the
+// analyzed function to line number.
+//
+// func_lines is the line range [start, end] of the analyzed function.
+function init_refs(refs, anchors, func_lines) {
+	var targets = Object.assign({}, refs, anchors);
+	window.addEventListener("hashchange", function() {
+		jump_to_hash(targets);
+	});
+	if (!jump_to_hash(targets) && refs !== null) {
+		jump_to_line(func_lines[0], false);
+	}
+	if (refs === null) {
+		return;
+	}
+	var tokens = get_leaf_tokens();
+	number_tokens(tokens);
+	for (var i = 0; i < tokens.length; i++) {
+		var token = tokens[i];
+		var ident = token.textContent;
+		// Skip SSA values of the analyzed function, unknown identifiers and
+		// definitions.
+		if (token.classList.contains("ssa_value") || !refs.hasOwnProperty(ident) || refs[ident] == token.dataset.line) {
+			continue;
+		}
+		token.classList.add("ll_ref");
+		token.title = "go to definition of " + ident + " (line " + refs[ident] + ")";
+		token.addEventListener("click", function(event) {
+			var ident = event.target.textContent;
+			window.location.hash = encodeURIComponent(ident);
+		});
+	}
+}
+
+// jump_to_hash scrolls the line specified by the URL fragment into view, and
+// reports whether the URL fragment specified a known target.
+//
+// targets maps from identifier or anchor name to line number.
+function jump_to_hash(targets) {
+	var target = decodeURIComponent(window.location.hash.substr(1));
+	if (!targets.hasOwnProperty(target)) {
+		return false;
+	}
+	jump_to_line(targets[target], true);
+	return true;
+}
+
+// jump_to_line scrolls the given line into view. If mark is set, the line
+// number is marked as the target of the jump.
+function jump_to_line(line, mark) {
+	var lineNumbers = document.querySelectorAll("span.lnt");
+	if (line < 1 || line > lineNumbers.length) {
+		return;
+	}
+	var targets = document.querySelectorAll(".ll_target");
+	for (var i = 0; i < targets.length; i++) {
+		targets[i].classList.remove("ll_target");
+	}
+	var lineNumber = lineNumbers[line-1];
+	if (mark) {
+		lineNumber.classList.add("ll_target");
+	}
+	lineNumber.scrollIntoView();
+}
+
+// number_tokens records the line number of each of the given tokens in their
+// "data-line" attribute.
+function number_tokens(tokens) {
+	if (tokens.length == 0 || tokens[0].dataset.line !== undefined) {
+		// Already numbered.
+		return;
+	}
+	// Locate the source code, as opposed to the line numbers.
+	var code = null;
+	var pres = document.querySelectorAll("pre");
+	for (var i = 0; i < pres.length; i++) {
+		if (pres[i].querySelector("span.lnt") === null) {
+			code = pres[i];
+		}
+	}
+	if (code === null) {
+		return;
+	}
+	// Count newlines of text preceding each token.
+	var line = 1;
+	var walker = document.createTreeWalker(code, NodeFilter.SHOW_TEXT, null, false);
+	while (walker.nextNode()) {
+		var node = walker.currentNode;
+		var parent = node.parentNode;
+		if (parent.dataset.line === undefined) {
+			parent.dataset.line = line;
+		}
+		line += node.nodeValue.split("\n").length - 1;
+	}
+}
+
+// in_lines reports whether the given token is located within the line range
+// [start, end].
+function in_lines(token, lines) {
+	var line = Number(token.dataset.line);
+	return line >= lines[0] && line <= lines[1];
+}
@@ -0,0 +1,35 @@
+// init_timeline populates the timeline of the overview page with a control
+// flow graph thumbnail of each step, and scrolls the thumbnail of the current
+// step into view. Thumbnails are loaded lazily, as functions may have hundreds
+// of steps.
+//
+// steps is the list of steps of the function, as specified by the steps
+// JavaScript file of the function.
+//
+// cur_page is the page number of the current overview page.
+function init_timeline(steps, cur_page) {
+	var timeline = document.getElementById("timeline");
+	var current = null;
+	for (var i = 0; i < steps.length; i++) {
+		var step = steps[i];
+		var link = document.createElement("a");
+		link.href = step.link;
+		link.title = step.desc;
+		var img = document.createElement("img");
+		img.src = step.img;
+		img.alt = step.narration;
+		img.loading = "lazy";
+		link.appendChild(img);
+		var label = document.createElement("span");
+		label.textContent = step.step + ". " + (step.prim === "" ? "original" : step.prim);
+		link.appendChild(label);
+		if (step.page == cur_page) {
+			link.className = "current";
+			current = link;
+		}
+		timeline.appendChild(link);
+	}
+	if (current !== null) {
+		timeline.scrollLeft = current.offsetLeft - (timeline.clientWidth - current.offsetWidth)/2;
+	}
+}